@@ -0,0 +1,312 @@
+// Package bedrock implements the game packet layer of minecraft: bedrock edition on top of buffer.Buffer,
+// which is carried inside of raknet frames as batches of length prefixed packets.
+package bedrock
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/gamevidea/binary/buffer"
+)
+
+// BatchID is the id every batch starts with
+const BatchID uint8 = 0xfe
+
+const (
+	// DefaultMaxBatchPackets is the maximum number of packets in a batch when no limit is configured
+	DefaultMaxBatchPackets = 1024
+	// DefaultMaxPacketSize is the maximum size of a packet in a batch when no limit is configured
+	DefaultMaxPacketSize = 1 << 21
+)
+
+// maxPrefixSize is the size of the largest length prefix of a packet
+const maxPrefixSize = 5
+
+// ErrInvalidBatch is the error returned when a batch does not start with BatchID
+var ErrInvalidBatch = errors.New("could not parse the batch as it does not start with the batch id")
+
+// ErrTooManyPackets is the error returned when a batch holds more packets than allowed
+var ErrTooManyPackets = errors.New("could not process the batch as it holds too many packets")
+
+// sizer is implemented by packets that know their encoded size in advance, such as the ones generated by
+// binarygen
+type sizer interface {
+	EncodedSize() int
+}
+
+// BatchReader iterates the packets of a batch as sub-buffers sharing the batch's internal slice
+type BatchReader struct {
+	// MaxPackets is the maximum number of packets in the batch. Zero means DefaultMaxBatchPackets.
+	MaxPackets int
+	// MaxPacketSize is the maximum size of a packet. Zero means DefaultMaxPacketSize.
+	MaxPacketSize int
+
+	b *buffer.Buffer
+	n int
+}
+
+// Reads the batch id from the buffer and returns a reader of the packets that follow it
+func NewBatchReader(b *buffer.Buffer) (*BatchReader, error) {
+	id, err := b.ReadUint8()
+	if err != nil {
+		return nil, err
+	}
+
+	if id != BatchID {
+		return nil, &buffer.DecodeError{Offset: b.Offset() - 1, Size: 1, Remaining: b.Remaining() + 1, Err: ErrInvalidBatch}
+	}
+
+	r := &BatchReader{}
+	r.Reset(b)
+
+	return r, nil
+}
+
+// Resets the reader to iterate the packets left in the provided buffer, such as a decompressed batch whose
+// id was already read. The limits are kept.
+func (r *BatchReader) Reset(b *buffer.Buffer) {
+	r.b = b
+	r.n = 0
+}
+
+// Returns the number of packets read so far
+func (r *BatchReader) Count() int {
+	return r.n
+}
+
+// Reads the next packet of the batch and returns it as a buffer sharing the batch's internal slice, which
+// inherits the batch buffer's decoding policy. It returns io.EOF once every packet was read.
+func (r *BatchReader) Next() (*buffer.Buffer, error) {
+	if r.b.Remaining() == 0 {
+		return nil, io.EOF
+	}
+
+	if r.n >= r.maxPackets() {
+		return nil, &buffer.DecodeError{Offset: r.b.Offset(), Remaining: r.b.Remaining(), Err: ErrTooManyPackets}
+	}
+
+	offset := r.b.Offset()
+
+	n, err := r.b.ReadLength(buffer.Layout{Prefix: buffer.PrefixVarUint32, MaxLength: r.maxPacketSize()})
+	if err != nil {
+		return nil, buffer.WithField(err, fmt.Sprintf("[%d]", r.n))
+	}
+
+	if n == 0 {
+		return nil, &buffer.DecodeError{Offset: offset, Size: 1, Remaining: r.b.Remaining() + 1, Field: fmt.Sprintf("[%d]", r.n), Err: buffer.ErrInvalidLength}
+	}
+
+	pk, err := r.b.Sub(n)
+	if err != nil {
+		return nil, buffer.WithField(err, fmt.Sprintf("[%d]", r.n))
+	}
+
+	r.n++
+	return pk, nil
+}
+
+// Returns the configured maximum number of packets or the default one
+func (r *BatchReader) maxPackets() int {
+	if r.MaxPackets > 0 {
+		return r.MaxPackets
+	}
+
+	return DefaultMaxBatchPackets
+}
+
+// Returns the configured maximum packet size or the default one
+func (r *BatchReader) maxPacketSize() int {
+	if r.MaxPacketSize > 0 {
+		return r.MaxPacketSize
+	}
+
+	return DefaultMaxPacketSize
+}
+
+// BatchWriter appends length prefixed packets to a batch, encoding them in place in the batch's buffer
+type BatchWriter struct {
+	// MaxPackets is the maximum number of packets in the batch. Zero means DefaultMaxBatchPackets.
+	MaxPackets int
+	// MaxPacketSize is the maximum size of a packet. Zero means DefaultMaxPacketSize.
+	MaxPacketSize int
+
+	b *buffer.Buffer
+	n int
+}
+
+// Writes the batch id to the buffer and returns a writer appending packets after it
+func NewBatchWriter(b *buffer.Buffer) (*BatchWriter, error) {
+	if err := b.WriteUint8(BatchID); err != nil {
+		return nil, err
+	}
+
+	w := &BatchWriter{}
+	w.Reset(b)
+
+	return w, nil
+}
+
+// Resets the writer to append packets to the provided buffer at its cursor without writing the batch id,
+// such as a batch that is compressed before the id is written. The limits are kept.
+func (w *BatchWriter) Reset(b *buffer.Buffer) {
+	w.b = b
+	w.n = 0
+}
+
+// Returns the number of packets written so far
+func (w *BatchWriter) Count() int {
+	return w.n
+}
+
+// Appends the provided packet payload preceded by its length. The cursor is left untouched if the operation
+// failed.
+func (w *BatchWriter) Write(pk []byte) error {
+	if err := w.check(len(pk)); err != nil {
+		return err
+	}
+
+	if err := w.b.WriteByteSlice(pk, buffer.BedrockLayout); err != nil {
+		return err
+	}
+
+	w.n++
+	return nil
+}
+
+// Encodes the provided packet directly into the batch preceded by its length. Packets that implement
+// EncodedSize, such as the ones generated by binarygen, are encoded after their length prefix, while others
+// are encoded after a reserved prefix and moved back in place once their size is known. The cursor and length
+// are left untouched if the operation failed.
+func (w *BatchWriter) Encode(pk buffer.Encoder) error {
+	if s, ok := pk.(sizer); ok {
+		return w.encodeSized(pk, s.EncodedSize())
+	}
+
+	if w.n >= w.maxPackets() {
+		return ErrTooManyPackets
+	}
+
+	// A packet in a fixed buffer is smaller than the bytes left, which bounds the size of its prefix. When it
+	// is one byte short of room, its prefix is smaller than the one reserved, so it is encoded once more after
+	// a shorter one.
+	reserved := buffer.VarUint32Size(uint32(w.maxPacketSize()))
+	if !w.b.Growable() {
+		reserved = min(reserved, buffer.VarUint32Size(uint32(max(w.b.Remaining()-1, 0))))
+	}
+
+	err := w.encodeReserved(pk, reserved)
+	if errors.Is(err, buffer.ErrEndOfFile) && !w.b.Growable() && reserved > 1 {
+		err = w.encodeReserved(pk, reserved-1)
+	}
+
+	return err
+}
+
+// Encodes a packet of an unknown size after a prefix of the provided size and moves it back in place once its
+// size is known
+func (w *BatchWriter) encodeReserved(pk buffer.Encoder, reserved int) error {
+	m, l := w.b.Mark(), w.b.Length()
+	start := w.b.Offset()
+
+	var zeros [maxPrefixSize]byte
+	if _, err := w.b.Write(zeros[:reserved]); err != nil {
+		w.b.Rewind(m)
+		return err
+	}
+
+	if err := pk.MarshalBinaryTo(w.b); err != nil {
+		w.b.Rewind(m)
+		return err
+	}
+
+	n := w.b.Offset() - start - reserved
+	if err := w.check(n); err != nil {
+		w.b.Rewind(m)
+		return err
+	}
+
+	prefix := buffer.VarUint32Size(uint32(n))
+	if prefix > reserved {
+		w.b.Rewind(m)
+		return buffer.ErrEndOfFile
+	}
+
+	slice := w.b.Slice()
+	copy(slice[start+prefix:], slice[start+reserved:start+reserved+n])
+
+	w.b.SetOffset(start)
+	if err := w.b.WriteVarUint32(uint32(n)); err != nil {
+		w.b.Rewind(m)
+		return err
+	}
+
+	// The bytes reserved beyond the prefix are not part of the batch if the buffer's length grew with them.
+	end := start + prefix + n
+	w.b.SetOffset(end)
+	if w.b.Length() > l {
+		w.b.Resize(max(end, l))
+	}
+
+	w.n++
+	return nil
+}
+
+// Encodes a packet of a known size after its length prefix
+func (w *BatchWriter) encodeSized(pk buffer.Encoder, n int) error {
+	if err := w.check(n); err != nil {
+		return err
+	}
+
+	m := w.b.Mark()
+	start := w.b.Offset()
+	if err := w.b.WriteVarUint32(uint32(n)); err != nil {
+		w.b.Rewind(m)
+		return err
+	}
+
+	if err := pk.MarshalBinaryTo(w.b); err != nil {
+		w.b.Rewind(m)
+		return err
+	}
+
+	if w.b.Offset()-start-buffer.VarUint32Size(uint32(n)) != n {
+		w.b.Rewind(m)
+		return fmt.Errorf("%T: encoded size does not match EncodedSize: %w", pk, buffer.ErrInvalidLength)
+	}
+
+	w.n++
+	return nil
+}
+
+// Validates that a packet of the provided size can be appended to the batch. Empty packets are rejected as
+// every packet starts with its header.
+func (w *BatchWriter) check(n int) error {
+	if w.n >= w.maxPackets() {
+		return ErrTooManyPackets
+	}
+
+	if n == 0 || n > w.maxPacketSize() {
+		return fmt.Errorf("packet of %d bytes: %w", n, buffer.ErrInvalidLength)
+	}
+
+	return nil
+}
+
+// Returns the configured maximum number of packets or the default one
+func (w *BatchWriter) maxPackets() int {
+	if w.MaxPackets > 0 {
+		return w.MaxPackets
+	}
+
+	return DefaultMaxBatchPackets
+}
+
+// Returns the configured maximum packet size or the default one
+func (w *BatchWriter) maxPacketSize() int {
+	if w.MaxPacketSize > 0 {
+		return w.MaxPacketSize
+	}
+
+	return DefaultMaxPacketSize
+}
@@ -0,0 +1,303 @@
+package bedrock
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/gamevidea/binary/buffer"
+)
+
+// rawPayload is a packet whose size is not known before it is encoded
+type rawPayload []byte
+
+func (p rawPayload) MarshalBinaryTo(b *buffer.Buffer) error {
+	_, err := b.Write(p)
+	return err
+}
+
+// sizedPayload is a packet that reports its encoded size in advance
+type sizedPayload struct {
+	data []byte
+	size int
+}
+
+func (p sizedPayload) MarshalBinaryTo(b *buffer.Buffer) error {
+	_, err := b.Write(p.data)
+	return err
+}
+
+func (p sizedPayload) EncodedSize() int {
+	return p.size
+}
+
+// Returns a batch holding the provided length prefixes and payloads as is, after the batch id
+func rawBatch(parts ...[]byte) *buffer.Buffer {
+	data := []byte{BatchID}
+	for _, p := range parts {
+		data = append(data, p...)
+	}
+
+	return buffer.From(data)
+}
+
+func TestBatchRoundTrip(t *testing.T) {
+	packets := [][]byte{{0x01}, bytes.Repeat([]byte{0x02}, 127), bytes.Repeat([]byte{0x03}, 128), bytes.Repeat([]byte{0x04}, 20000)}
+
+	b := buffer.NewGrowable(0)
+	w, err := NewBatchWriter(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, pk := range packets {
+		if err := w.Write(pk); err != nil {
+			t.Fatalf("Write() error = %v", err)
+		}
+	}
+
+	if w.Count() != len(packets) {
+		t.Fatalf("Count() = %d, want %d", w.Count(), len(packets))
+	}
+
+	b.Resize(b.Offset())
+	b.SetOffset(0)
+
+	r, err := NewBatchReader(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i, want := range packets {
+		pk, err := r.Next()
+		if err != nil {
+			t.Fatalf("Next() of packet %d error = %v", i, err)
+		}
+
+		if !bytes.Equal(pk.Slice(), want) || pk.Offset() != 0 {
+			t.Fatalf("Next() of packet %d = %d bytes, want %d", i, pk.Length(), len(want))
+		}
+	}
+
+	if _, err := r.Next(); err != io.EOF {
+		t.Fatalf("Next() after the last packet error = %v, want io.EOF", err)
+	}
+
+	if r.Count() != len(packets) {
+		t.Fatalf("Count() = %d, want %d", r.Count(), len(packets))
+	}
+}
+
+func TestBatchReaderInvalidID(t *testing.T) {
+	if _, err := NewBatchReader(buffer.From([]byte{0x00, 0x01, 0x01})); !errors.Is(err, ErrInvalidBatch) {
+		t.Fatalf("NewBatchReader() error = %v, want ErrInvalidBatch", err)
+	}
+}
+
+func TestBatchReaderSubBuffers(t *testing.T) {
+	b := rawBatch([]byte{0x02, 0xaa, 0xbb}, []byte{0x01, 0xcc})
+
+	r, err := NewBatchReader(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	first, err := r.Next()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	second, err := r.Next()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The packets share the batch's slice without copying it.
+	first.Slice()[0] = 0x11
+	if b.Slice()[2] != 0x11 {
+		t.Fatal("Next() returned a copy of the packet instead of sharing the batch's slice")
+	}
+
+	// Each packet is bounded to its own bytes, so neither reads nor writes reach the next one.
+	if first.Length() != 2 || second.Length() != 1 {
+		t.Fatalf("Next() returned packets of %d and %d bytes, want 2 and 1", first.Length(), second.Length())
+	}
+
+	first.SetOffset(first.Length())
+	if _, err := first.ReadUint8(); !errors.Is(err, buffer.ErrEndOfFile) {
+		t.Fatalf("ReadUint8() past the end of a packet error = %v, want ErrEndOfFile", err)
+	}
+
+	if err := first.WriteUint8(0xff); !errors.Is(err, buffer.ErrEndOfFile) || b.Slice()[4] != 0x01 {
+		t.Fatalf("WriteUint8() past the end of a packet error = %v, want ErrEndOfFile without touching the next one", err)
+	}
+}
+
+func TestBatchReaderLimits(t *testing.T) {
+	tests := []struct {
+		name   string
+		batch  *buffer.Buffer
+		reader BatchReader
+		read   int
+		want   error
+	}{
+		{
+			name:   "too many packets",
+			batch:  rawBatch([]byte{0x01, 0xaa}, []byte{0x01, 0xbb}, []byte{0x01, 0xcc}),
+			reader: BatchReader{MaxPackets: 2},
+			read:   2,
+			want:   ErrTooManyPackets,
+		},
+		{
+			name:   "packet larger than the limit",
+			batch:  rawBatch([]byte{0x01, 0xaa}, []byte{0x05, 1, 2, 3, 4, 5}),
+			reader: BatchReader{MaxPacketSize: 4},
+			read:   1,
+			want:   buffer.ErrInvalidLength,
+		},
+		{
+			name:  "zero length packet",
+			batch: rawBatch([]byte{0x01, 0xaa}, []byte{0x00}, []byte{0x01, 0xbb}),
+			read:  1,
+			want:  buffer.ErrInvalidLength,
+		},
+		{
+			name:  "packet beyond the end of the batch",
+			batch: rawBatch([]byte{0x05, 1, 2}),
+			want:  buffer.ErrEndOfFile,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := tt.batch.ReadUint8(); err != nil {
+				t.Fatal(err)
+			}
+
+			r := tt.reader
+			r.Reset(tt.batch)
+
+			for i := 0; i < tt.read; i++ {
+				if _, err := r.Next(); err != nil {
+					t.Fatalf("Next() of packet %d error = %v", i, err)
+				}
+			}
+
+			_, err := r.Next()
+
+			var decodeErr *buffer.DecodeError
+			if !errors.Is(err, tt.want) || !errors.As(err, &decodeErr) {
+				t.Fatalf("Next() error = %v, want a *DecodeError wrapping %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestBatchWriterLimits(t *testing.T) {
+	w := &BatchWriter{MaxPackets: 1, MaxPacketSize: 4}
+	w.Reset(buffer.NewGrowable(0))
+
+	if err := w.Write(nil); !errors.Is(err, buffer.ErrInvalidLength) {
+		t.Fatalf("Write() of an empty packet error = %v, want ErrInvalidLength", err)
+	}
+
+	if err := w.Encode(rawPayload{1, 2, 3, 4, 5}); !errors.Is(err, buffer.ErrInvalidLength) {
+		t.Fatalf("Encode() of a packet larger than the limit error = %v, want ErrInvalidLength", err)
+	}
+
+	if err := w.Encode(rawPayload{1}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := w.Write([]byte{1}); !errors.Is(err, ErrTooManyPackets) {
+		t.Fatalf("Write() past the packet limit error = %v, want ErrTooManyPackets", err)
+	}
+}
+
+func TestBatchWriterEncode(t *testing.T) {
+	for _, n := range []int{1, 127, 128, 16383, 16384, 20000} {
+		data := bytes.Repeat([]byte{0x5a}, n)
+
+		want := buffer.NewGrowable(0)
+		if err := (&BatchWriter{b: want}).Write(data); err != nil {
+			t.Fatal(err)
+		}
+
+		for _, pk := range []buffer.Encoder{rawPayload(data), sizedPayload{data: data, size: n}} {
+			// The packet follows another so that it is moved back within the batch.
+			b := buffer.NewGrowable(0)
+			if err := b.WriteUint8(0xff); err != nil {
+				t.Fatal(err)
+			}
+
+			w := &BatchWriter{}
+			w.Reset(b)
+			if err := w.Encode(pk); err != nil {
+				t.Fatalf("Encode() of %T of %d bytes error = %v", pk, n, err)
+			}
+
+			if !bytes.Equal(b.Bytes()[1:], want.Bytes()) {
+				t.Fatalf("Encode() of %T of %d bytes did not match Write()", pk, n)
+			}
+
+			// No byte of the reserved prefix is left past the packet.
+			if b.Length() != b.Offset() {
+				t.Fatalf("Encode() of %T of %d bytes left a length of %d at offset %d", pk, n, b.Length(), b.Offset())
+			}
+		}
+	}
+}
+
+func TestBatchWriterEncodeFixed(t *testing.T) {
+	for _, n := range []int{1, 127, 128, 16383, 16384} {
+		data := bytes.Repeat([]byte{0x5a}, n)
+		size := buffer.VarUint32Size(uint32(n)) + n
+
+		// The packet fits exactly with its own prefix, even though it does not with a larger one reserved.
+		b := buffer.New(size)
+		w := &BatchWriter{}
+		w.Reset(b)
+		if err := w.Encode(rawPayload(data)); err != nil {
+			t.Fatalf("Encode() of %d bytes into %d bytes error = %v", n, size, err)
+		}
+
+		if b.Offset() != size || w.Count() != 1 {
+			t.Fatalf("Encode() of %d bytes wrote %d bytes, want %d", n, b.Offset(), size)
+		}
+
+		// One byte short of room fails without writing anything.
+		b = buffer.New(size - 1)
+		w.Reset(b)
+		if err := w.Encode(rawPayload(data)); !errors.Is(err, buffer.ErrEndOfFile) {
+			t.Fatalf("Encode() of %d bytes into %d bytes error = %v, want ErrEndOfFile", n, size-1, err)
+		}
+
+		if b.Offset() != 0 || b.Length() != size-1 || w.Count() != 0 {
+			t.Fatalf("Encode() of %d bytes that failed left the cursor at %d", n, b.Offset())
+		}
+	}
+}
+
+func TestBatchWriterEncodeRollback(t *testing.T) {
+	tests := []buffer.Encoder{
+		sizedPayload{data: []byte{1, 2, 3}, size: 2},
+		rawPayload(nil),
+	}
+
+	for _, pk := range tests {
+		b := buffer.NewGrowable(0)
+		w, err := NewBatchWriter(b)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if err := w.Encode(pk); !errors.Is(err, buffer.ErrInvalidLength) {
+			t.Fatalf("Encode() of %T error = %v, want ErrInvalidLength", pk, err)
+		}
+
+		if b.Offset() != 1 || b.Length() != 1 || w.Count() != 0 {
+			t.Fatalf("Encode() of %T that failed left offset %d and length %d", pk, b.Offset(), b.Length())
+		}
+	}
+}
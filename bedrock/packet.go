@@ -0,0 +1,186 @@
+package bedrock
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/gamevidea/binary/buffer"
+)
+
+const (
+	// MaxPacketID is the largest packet id as it is packed in 10 bits of the header
+	MaxPacketID = 0x3ff
+	// MaxSubClientID is the largest sub-client id as it is packed in 2 bits of the header
+	MaxSubClientID = 0x3
+)
+
+const (
+	// senderShift is the position of the sender sub-client id in the header
+	senderShift = 10
+	// targetShift is the position of the target sub-client id in the header
+	targetShift = 12
+	// headerBits is the number of bits of the header that are in use
+	headerBits = 14
+)
+
+// ErrInvalidHeader is the error returned when a packet header holds ids that do not fit their bits or has
+// bits set beyond them
+var ErrInvalidHeader = errors.New("could not process the packet header as its ids are out of range")
+
+// PacketHeader precedes every game packet. It packs the packet id with the ids of the split-screen sub-clients
+// sending and receiving the packet into a varuint32.
+type PacketHeader struct {
+	PacketID        uint16
+	SenderSubClient uint8
+	TargetSubClient uint8
+}
+
+// Returns the varuint32 the header is encoded as, or an error if an id does not fit its bits
+func (h *PacketHeader) pack() (uint32, error) {
+	if h.PacketID > MaxPacketID || h.SenderSubClient > MaxSubClientID || h.TargetSubClient > MaxSubClientID {
+		return 0, ErrInvalidHeader
+	}
+
+	return uint32(h.PacketID) | uint32(h.SenderSubClient)<<senderShift | uint32(h.TargetSubClient)<<targetShift, nil
+}
+
+// Writes the header to the buffer and returns an error if the operation was unsuccessful
+func (h *PacketHeader) MarshalBinaryTo(b *buffer.Buffer) error {
+	v, err := h.pack()
+	if err != nil {
+		return err
+	}
+
+	return b.WriteVarUint32(v)
+}
+
+// Reads the header from the buffer and returns an error if the operation was unsuccessful
+func (h *PacketHeader) UnmarshalBinaryFrom(b *buffer.Buffer) error {
+	offset := b.Offset()
+
+	v, err := b.ReadVarUint32()
+	if err != nil {
+		return err
+	}
+
+	if v>>headerBits != 0 {
+		return &buffer.DecodeError{Offset: offset, Size: b.Offset() - offset, Remaining: b.Remaining() + b.Offset() - offset, Err: ErrInvalidHeader}
+	}
+
+	h.PacketID = uint16(v & MaxPacketID)
+	h.SenderSubClient = uint8(v >> senderShift & MaxSubClientID)
+	h.TargetSubClient = uint8(v >> targetShift & MaxSubClientID)
+
+	return nil
+}
+
+// Returns the number of bytes the header takes when encoded
+func (h *PacketHeader) EncodedSize() int {
+	v, _ := h.pack()
+	return buffer.VarUint32Size(v)
+}
+
+// Packet is a game packet, which is encoded after its header
+type Packet interface {
+	buffer.Codec
+	// ID returns the id of the packet written in its header
+	ID() uint16
+}
+
+// RawPacket is a packet whose id is not registered. It holds the payload that follows the header as is, so
+// that unknown packets can be forwarded or skipped instead of breaking decoding.
+type RawPacket struct {
+	PacketID uint16
+	// Payload is the packet without its header. Decoding sets it to a shared reference to the buffer's
+	// internal slice.
+	Payload []byte
+}
+
+// Returns the id of the packet the payload belongs to
+func (pk *RawPacket) ID() uint16 {
+	return pk.PacketID
+}
+
+// Writes the payload to the buffer and returns an error if the operation was unsuccessful
+func (pk *RawPacket) MarshalBinaryTo(b *buffer.Buffer) error {
+	_, err := b.Write(pk.Payload)
+	return err
+}
+
+// Reads the rest of the buffer as the payload and returns an error if the operation was unsuccessful
+func (pk *RawPacket) UnmarshalBinaryFrom(b *buffer.Buffer) error {
+	pk.Payload = nil
+	if b.Remaining() == 0 {
+		return nil
+	}
+
+	v, err := b.Get(b.Remaining())
+	if err != nil {
+		return err
+	}
+
+	pk.Payload = v
+	return nil
+}
+
+// Returns the number of bytes the payload takes when encoded
+func (pk *RawPacket) EncodedSize() int {
+	return len(pk.Payload)
+}
+
+// Registry maps packet ids to constructors of the packets they decode into. Packets are registered up front,
+// after which a Registry is safe for concurrent use.
+type Registry struct {
+	packets map[uint16]func() Packet
+}
+
+// Creates and returns a new empty Registry
+func NewRegistry() *Registry {
+	return &Registry{packets: make(map[uint16]func() Packet)}
+}
+
+// Registers the constructor of the packet with the provided id, replacing any constructor registered before
+func (r *Registry) Register(id uint16, fn func() Packet) {
+	if r.packets == nil {
+		r.packets = make(map[uint16]func() Packet)
+	}
+
+	r.packets[id] = fn
+}
+
+// Returns the constructor of the packet with the provided id and reports whether it was registered
+func (r *Registry) Lookup(id uint16) (func() Packet, bool) {
+	fn, ok := r.packets[id]
+	return fn, ok
+}
+
+// Reads a header and the packet that follows it from the buffer, which usually holds a single packet of a
+// batch. Packets whose id is not registered are returned as a *RawPacket.
+func (r *Registry) Read(b *buffer.Buffer) (PacketHeader, Packet, error) {
+	var h PacketHeader
+	if err := h.UnmarshalBinaryFrom(b); err != nil {
+		return h, nil, buffer.WithField(err, "header")
+	}
+
+	var pk Packet = &RawPacket{PacketID: h.PacketID}
+	if fn, ok := r.packets[h.PacketID]; ok {
+		pk = fn()
+	}
+
+	if err := pk.UnmarshalBinaryFrom(b); err != nil {
+		return h, nil, fmt.Errorf("%T: %w", pk, err)
+	}
+
+	return h, pk, nil
+}
+
+// Writes the provided header followed by the packet to the buffer and returns an error if the operation was
+// unsuccessful. The header's packet id is set to the packet's.
+func WritePacket(b *buffer.Buffer, h PacketHeader, pk Packet) error {
+	h.PacketID = pk.ID()
+	if err := h.MarshalBinaryTo(b); err != nil {
+		return fmt.Errorf("%T: %w", pk, err)
+	}
+
+	return pk.MarshalBinaryTo(b)
+}
@@ -0,0 +1,182 @@
+package bedrock
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/gamevidea/binary/buffer"
+)
+
+// textPacket is a registered packet holding a single string
+type textPacket struct {
+	Message string
+}
+
+func (pk *textPacket) ID() uint16 {
+	return 0x09
+}
+
+func (pk *textPacket) MarshalBinaryTo(b *buffer.Buffer) error {
+	return b.WriteString(pk.Message, buffer.BedrockLayout)
+}
+
+func (pk *textPacket) UnmarshalBinaryFrom(b *buffer.Buffer) (err error) {
+	pk.Message, err = b.ReadString(buffer.BedrockLayout)
+	return err
+}
+
+// Returns the buffer the provided header is encoded into, positioned at its start
+func header(t *testing.T, h PacketHeader) *buffer.Buffer {
+	t.Helper()
+
+	b := buffer.NewGrowable(0)
+	if err := h.MarshalBinaryTo(b); err != nil {
+		t.Fatalf("MarshalBinaryTo() of %+v error = %v", h, err)
+	}
+	b.Resize(b.Offset())
+	b.SetOffset(0)
+
+	return b
+}
+
+func TestPacketHeaderBits(t *testing.T) {
+	tests := []struct {
+		h    PacketHeader
+		want uint32
+	}{
+		{PacketHeader{}, 0},
+		{PacketHeader{PacketID: 0x01}, 0x01},
+		{PacketHeader{PacketID: MaxPacketID}, 0x3ff},
+		{PacketHeader{SenderSubClient: 1}, 1 << 10},
+		{PacketHeader{TargetSubClient: 1}, 1 << 12},
+		{PacketHeader{PacketID: 0x2a5, SenderSubClient: 2, TargetSubClient: 3}, 0x2a5 | 2<<10 | 3<<12},
+		{PacketHeader{PacketID: MaxPacketID, SenderSubClient: MaxSubClientID, TargetSubClient: MaxSubClientID}, 0x3fff},
+	}
+
+	for _, tt := range tests {
+		b := header(t, tt.h)
+		if b.Length() != tt.h.EncodedSize() {
+			t.Fatalf("EncodedSize() of %+v = %d, want %d", tt.h, tt.h.EncodedSize(), b.Length())
+		}
+
+		v, err := b.ReadVarUint32()
+		if err != nil || v != tt.want {
+			t.Fatalf("MarshalBinaryTo() of %+v wrote %#x, want %#x", tt.h, v, tt.want)
+		}
+
+		b.SetOffset(0)
+
+		var got PacketHeader
+		if err := got.UnmarshalBinaryFrom(b); err != nil || got != tt.h {
+			t.Fatalf("UnmarshalBinaryFrom() = %+v, %v, want %+v", got, err, tt.h)
+		}
+	}
+}
+
+func TestPacketHeaderOutOfRange(t *testing.T) {
+	for _, h := range []PacketHeader{
+		{PacketID: MaxPacketID + 1},
+		{SenderSubClient: MaxSubClientID + 1},
+		{TargetSubClient: MaxSubClientID + 1},
+	} {
+		if err := h.MarshalBinaryTo(buffer.NewGrowable(0)); !errors.Is(err, ErrInvalidHeader) {
+			t.Fatalf("MarshalBinaryTo() of %+v error = %v, want ErrInvalidHeader", h, err)
+		}
+	}
+
+	// Any bit set beyond the 14 bits of the header is rejected rather than dropped.
+	for _, v := range []uint32{1 << 14, 1<<14 | 0x01, 0xffffffff} {
+		b := buffer.NewGrowable(0)
+		if err := b.WriteVarUint32(v); err != nil {
+			t.Fatal(err)
+		}
+		b.Resize(b.Offset())
+		b.SetOffset(0)
+
+		var h PacketHeader
+		var decodeErr *buffer.DecodeError
+		if err := h.UnmarshalBinaryFrom(b); !errors.Is(err, ErrInvalidHeader) || !errors.As(err, &decodeErr) || decodeErr.Offset != 0 {
+			t.Fatalf("UnmarshalBinaryFrom() of %#x error = %v, want a *DecodeError wrapping ErrInvalidHeader", v, err)
+		}
+	}
+}
+
+func TestRegistryRoundTrip(t *testing.T) {
+	r := NewRegistry()
+	r.Register(0x09, func() Packet { return &textPacket{} })
+
+	b := buffer.NewGrowable(0)
+	if err := WritePacket(b, PacketHeader{PacketID: 0x3ff, SenderSubClient: 1, TargetSubClient: 2}, &textPacket{Message: "hello"}); err != nil {
+		t.Fatalf("WritePacket() error = %v", err)
+	}
+	b.Resize(b.Offset())
+	b.SetOffset(0)
+
+	h, pk, err := r.Read(b)
+	if err != nil {
+		t.Fatalf("Read() error = %v", err)
+	}
+
+	// The header's packet id is the packet's, whatever the header passed held.
+	if want := (PacketHeader{PacketID: 0x09, SenderSubClient: 1, TargetSubClient: 2}); h != want {
+		t.Fatalf("Read() header = %+v, want %+v", h, want)
+	}
+
+	if text, ok := pk.(*textPacket); !ok || text.Message != "hello" || b.Remaining() != 0 {
+		t.Fatalf("Read() = %#v with %d bytes left, want the text packet", pk, b.Remaining())
+	}
+}
+
+func TestRegistryUnknownPacket(t *testing.T) {
+	r := NewRegistry()
+	r.Register(0x09, func() Packet { return &textPacket{} })
+
+	payload := []byte{0x01, 0x02, 0x03}
+
+	b := buffer.NewGrowable(0)
+	if err := WritePacket(b, PacketHeader{TargetSubClient: 1}, &RawPacket{PacketID: 0x90, Payload: payload}); err != nil {
+		t.Fatalf("WritePacket() error = %v", err)
+	}
+	b.Resize(b.Offset())
+	b.SetOffset(0)
+
+	h, pk, err := r.Read(b)
+	if err != nil {
+		t.Fatalf("Read() error = %v", err)
+	}
+
+	raw, ok := pk.(*RawPacket)
+	if !ok || raw.ID() != 0x90 || h.PacketID != 0x90 || h.TargetSubClient != 1 || !bytes.Equal(raw.Payload, payload) {
+		t.Fatalf("Read() = %+v, %#v, want a *RawPacket of id 0x90", h, pk)
+	}
+
+	// The payload refers to the buffer's internal slice.
+	b.Slice()[b.Length()-1] = 0xff
+	if raw.Payload[2] != 0xff {
+		t.Fatal("Read() copied the payload of an unknown packet instead of sharing the buffer's slice")
+	}
+}
+
+func TestRegistryReadErrors(t *testing.T) {
+	r := NewRegistry()
+	r.Register(0x09, func() Packet { return &textPacket{} })
+
+	// A text packet whose string is longer than the bytes left.
+	b := buffer.From([]byte{0x09, 0x05, 'h', 'i'})
+	if _, _, err := r.Read(b); !errors.Is(err, buffer.ErrEndOfFile) {
+		t.Fatalf("Read() of a truncated packet error = %v, want ErrEndOfFile", err)
+	}
+
+	b = buffer.From([]byte{0x80, 0x80, 0x01})
+	var decodeErr *buffer.DecodeError
+	if _, _, err := r.Read(b); !errors.Is(err, ErrInvalidHeader) || !errors.As(err, &decodeErr) || decodeErr.Field != "header" {
+		t.Fatalf("Read() of an invalid header error = %v, want a *DecodeError of field header", err)
+	}
+
+	// A packet id that does not fit its bits fails before the packet is written.
+	b = buffer.NewGrowable(0)
+	if err := WritePacket(b, PacketHeader{}, &RawPacket{PacketID: MaxPacketID + 1}); !errors.Is(err, ErrInvalidHeader) || b.Offset() != 0 {
+		t.Fatalf("WritePacket() of an out of range id error = %v, want ErrInvalidHeader", err)
+	}
+}
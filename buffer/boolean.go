@@ -7,7 +7,8 @@ const (
 	falseByte uint8 = 0x00
 )
 
-// Reads a boolean from the buffer and returns it
+// Reads a boolean from the buffer and returns it. Bytes other than 0 and 1 are rejected unless the buffer's
+// policy is lenient for booleans.
 func (b *Buffer) ReadBool() (bool, error) {
 	byte, err := b.ReadUint8()
 	if err != nil {
@@ -20,7 +21,11 @@ func (b *Buffer) ReadBool() (bool, error) {
 	case falseByte:
 		return false, nil
 	default:
-		return false, ErrInvalidBool
+		if lenient(b.policy.Bool, TolerateStrict) {
+			return true, nil
+		}
+
+		return false, b.decodeError(b.offset-1, 1, ErrInvalidBool)
 	}
 }
 
@@ -1,5 +1,11 @@
 package buffer
 
+import "io"
+
+// minGrowableCapacity is the smallest capacity a growable buffer grows to, so that buffers created with a
+// tiny or zero capacity do not reallocate on every write.
+const minGrowableCapacity = 64
+
 // Represents a fixed size buffer with zero additional memory allocations. It provides fastest methods to read
 // and write various datatypes that are serialized to and from a minecraft network wire.
 type Buffer struct {
@@ -7,6 +13,23 @@ type Buffer struct {
 	cap    int
 	len    int
 	offset int
+
+	// growable reports whether the buffer reallocates its internal slice on writes that would otherwise
+	// fail with ErrEndOfFile.
+	growable bool
+
+	// family is the AF_INET6 value written in ipv6 addresses that do not carry their own. Zero means
+	// Inet6Windows.
+	family Inet6Family
+
+	// policy controls the tolerance of decoding operations for malformed values
+	policy Policy
+
+	// pool is the pool the buffer was allocated by, if any, and released reports whether it was returned
+	// to it. epoch counts the returns, so that marks taken before one can not be rewound to.
+	pool     *Pool
+	released bool
+	epoch    uint32
 }
 
 // Creates and returns a new Buffer of provided capacity
@@ -19,6 +42,18 @@ func New(cap int) *Buffer {
 	}
 }
 
+// Creates and returns a new growable Buffer of provided initial capacity. Unlike the buffers returned by
+// New, writes beyond the buffer's length double the internal slice instead of failing with ErrEndOfFile.
+func NewGrowable(cap int) *Buffer {
+	return &Buffer{
+		slice:    make([]byte, cap),
+		cap:      cap,
+		len:      cap,
+		offset:   0,
+		growable: true,
+	}
+}
+
 // Creates a new buffer from the provided slice
 func From(slice []byte) *Buffer {
 	return &Buffer{
@@ -29,6 +64,11 @@ func From(slice []byte) *Buffer {
 	}
 }
 
+// Returns whether the buffer grows its internal slice on demand
+func (b *Buffer) Growable() bool {
+	return b.growable
+}
+
 // Returns the capacity of the buffer
 func (b *Buffer) Capacity() int {
 	return b.cap
@@ -66,6 +106,38 @@ func (b *Buffer) Reset() {
 	b.len = b.cap
 }
 
+// Reports whether n bytes can be written at the buffer's cursor. Growable buffers reallocate their internal
+// slice if there is not enough space left, while fixed buffers never allocate.
+func (b *Buffer) writable(n int) bool {
+	return b.len-b.offset >= n || b.grow(n)
+}
+
+// Grows the internal slice of a growable buffer so that n bytes can be written at the buffer's cursor.
+// The capacity is at least doubled so that repeated writes are amortized, while the length only grows to
+// the end of the write so that a length restricted with Resize is not extended beyond what was written.
+func (b *Buffer) grow(n int) bool {
+	if !b.growable || n < 0 {
+		return false
+	}
+
+	need := b.offset + n
+	if need <= b.cap {
+		b.len = need
+		return true
+	}
+
+	cap := max(2*b.cap, need, minGrowableCapacity)
+
+	slice := make([]byte, cap)
+	copy(slice, b.slice[:b.cap])
+
+	b.slice = slice
+	b.cap = cap
+	b.len = need
+
+	return true
+}
+
 // Returns a shared reference to the buffer's internal slice.
 func (b *Buffer) Slice() []byte {
 	return b.slice
@@ -77,11 +149,15 @@ func (b *Buffer) Bytes() []byte {
 	return b.slice[:b.offset]
 }
 
-// Shifts the buffer's offset by the number of bytes passed. Returns an error if the operation
-// failed.
+// Shifts the buffer's offset by the number of bytes passed, which may move it up to the end of the buffer.
+// Returns an error if the operation failed.
 func (b *Buffer) Shift(n int) error {
-	if b.len-b.offset-n < 1 {
-		return ErrEndOfFile
+	if n < 0 {
+		return b.decodeError(b.offset, n, ErrInvalidOffset)
+	}
+
+	if b.len-b.offset < n {
+		return b.decodeError(b.offset, n, ErrEndOfFile)
 	}
 
 	b.offset += n
@@ -92,7 +168,7 @@ func (b *Buffer) Shift(n int) error {
 func (b *Buffer) Get(bytes int) ([]byte, error) {
 	n := b.len - b.offset
 	if n < 1 {
-		return nil, ErrEndOfFile
+		return nil, b.decodeError(b.offset, bytes, ErrEndOfFile)
 	}
 
 	l := min(n, bytes)
@@ -102,32 +178,99 @@ func (b *Buffer) Get(bytes int) ([]byte, error) {
 	return slice, nil
 }
 
-// Reads the content until either EOF is reached or the maximum capacity of the provided slice
-// gets fully used.
-func (b *Buffer) Read(buf []byte) error {
+// Returns a fixed buffer over the next n bytes of the buffer and moves the cursor past them. The returned
+// buffer shares the internal slice but has its own cursor and length, so that a nested decoder cannot read or
+// write beyond the n bytes. It inherits the buffer's decoding policy and AF_INET6 value, while the offsets of
+// its decode errors are relative to its own start.
+func (b *Buffer) Sub(n int) (*Buffer, error) {
+	if n < 0 {
+		return nil, b.decodeError(b.offset, n, ErrInvalidLength)
+	}
+
+	if b.len-b.offset < n {
+		return nil, b.decodeError(b.offset, n, ErrEndOfFile)
+	}
+
+	sub := b.view(b.offset, b.offset+n)
+	b.offset += n
+
+	return sub, nil
+}
+
+// Returns a fixed buffer over the bytes of the buffer between the provided start and end offsets without
+// moving the cursor. Like the one returned by Sub, it shares the internal slice but has its own cursor and
+// length.
+func (b *Buffer) View(start, end int) (*Buffer, error) {
+	if start < 0 || start > end {
+		return nil, b.decodeError(start, end-start, ErrInvalidOffset)
+	}
+
+	if end > b.len {
+		return nil, b.decodeError(start, end-start, ErrEndOfFile)
+	}
+
+	return b.view(start, end), nil
+}
+
+// Returns a fixed buffer over the internal slice between the provided offsets, which must be within bounds.
+// Its capacity ends at the end offset, so that it cannot be grown or reset into the bytes that follow.
+func (b *Buffer) view(start, end int) *Buffer {
+	return &Buffer{
+		slice:  b.slice[start:end:end],
+		cap:    end - start,
+		len:    end - start,
+		offset: 0,
+		family: b.family,
+		policy: b.policy,
+	}
+}
+
+// Reads up to len(buf) bytes from the buffer into the provided slice and returns the number of bytes read.
+// It returns io.EOF once no bytes are left to be read, implementing io.Reader.
+func (b *Buffer) Read(buf []byte) (int, error) {
+	if len(buf) == 0 {
+		return 0, nil
+	}
+
 	n := b.len - b.offset
 	if n < 1 {
-		return ErrEndOfFile
+		return 0, io.EOF
 	}
 
 	l := min(n, len(buf))
 	copy(buf[:l], b.slice[b.offset:b.offset+l])
 
 	b.offset += l
-	return nil
+	return l, nil
 }
 
-// Writes the contents of the provided slice until either EOF is reached or the maximum capacity of
-// the underlying buffer gets fully used.
-func (b *Buffer) Write(buf []byte) error {
-	n := b.len - b.offset
-	if n < 1 {
-		return ErrEndOfFile
+// Writes the contents of the provided slice to the buffer and returns the number of bytes written. Growable
+// buffers make room for the whole slice, while fixed buffers write as much as fits and return ErrEndOfFile
+// if the slice was written partially, implementing io.Writer.
+func (b *Buffer) Write(buf []byte) (int, error) {
+	if b.writable(len(buf)) {
+		copy(b.slice[b.offset:b.offset+len(buf)], buf)
+		b.offset += len(buf)
+
+		return len(buf), nil
 	}
 
-	l := min(n, len(buf))
+	l := max(b.len-b.offset, 0)
 	copy(b.slice[b.offset:b.offset+l], buf[:l])
 
-	b.offset += n
+	b.offset += l
+	return l, ErrEndOfFile
+}
+
+// Reads exactly len(buf) bytes from the buffer into the provided slice and moves the cursor past them. Unlike
+// Read, ErrEndOfFile is returned and nothing is consumed if fewer bytes are left.
+func (b *Buffer) ReadFull(buf []byte) error {
+	if b.len-b.offset < len(buf) {
+		return b.decodeError(b.offset, len(buf), ErrEndOfFile)
+	}
+
+	copy(buf, b.slice[b.offset:b.offset+len(buf)])
+	b.offset += len(buf)
+
 	return nil
 }
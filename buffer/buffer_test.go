@@ -0,0 +1,250 @@
+package buffer
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/gamevidea/binary/byteorder"
+)
+
+func TestGrowableWrite(t *testing.T) {
+	b := NewGrowable(0)
+	data := bytes.Repeat([]byte{0xab}, 1000)
+
+	if _, err := b.Write(data); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+
+	if !bytes.Equal(b.Bytes(), data) || b.Length() != len(data) || b.Capacity() < len(data) {
+		t.Fatalf("Write() left offset %d, length %d and capacity %d", b.Offset(), b.Length(), b.Capacity())
+	}
+}
+
+func TestGrowablePreservesResize(t *testing.T) {
+	b := NewGrowable(64)
+	b.Resize(8)
+
+	if err := b.WriteUint64(1, 0); err != nil {
+		t.Fatalf("WriteUint64() error = %v", err)
+	}
+
+	// Writing past the resized length grows it by the bytes written, not to the whole capacity.
+	if err := b.WriteUint16(2, 0); err != nil {
+		t.Fatalf("WriteUint16() error = %v", err)
+	}
+
+	if b.Length() != 10 || b.Remaining() != 0 {
+		t.Fatalf("length = %d and remaining = %d after writing past Resize, want 10 and 0", b.Length(), b.Remaining())
+	}
+
+	if b.Capacity() != 64 {
+		t.Fatalf("capacity = %d, want 64", b.Capacity())
+	}
+}
+
+func TestFixedWriteDoesNotGrow(t *testing.T) {
+	b := New(4)
+
+	n, err := b.Write([]byte{1, 2, 3, 4, 5})
+	if !errors.Is(err, ErrEndOfFile) || n != 4 || b.Capacity() != 4 {
+		t.Fatalf("Write() = %d, %v with capacity %d, want a partial write of 4 bytes and ErrEndOfFile", n, err, b.Capacity())
+	}
+}
+
+func TestShift(t *testing.T) {
+	b := From([]byte{1, 2, 3, 4})
+
+	// Shifting exactly to the end of the buffer is allowed, such as when skipping the rest of a payload.
+	if err := b.Shift(b.Remaining()); err != nil || b.Offset() != 4 {
+		t.Fatalf("Shift(Remaining()) error = %v at offset %d, want offset 4", err, b.Offset())
+	}
+
+	if err := b.Shift(0); err != nil {
+		t.Fatalf("Shift(0) at the end error = %v", err)
+	}
+
+	if err := b.Shift(1); !errors.Is(err, ErrEndOfFile) || b.Offset() != 4 {
+		t.Fatalf("Shift(1) past the end error = %v at offset %d, want ErrEndOfFile at 4", err, b.Offset())
+	}
+
+	if err := b.Shift(-1); !errors.Is(err, ErrInvalidOffset) || b.Offset() != 4 {
+		t.Fatalf("Shift(-1) error = %v at offset %d, want ErrInvalidOffset at 4", err, b.Offset())
+	}
+}
+
+func TestReadFull(t *testing.T) {
+	b := From([]byte{1, 2, 3})
+
+	buf := make([]byte, 2)
+	if err := b.ReadFull(buf); err != nil || !bytes.Equal(buf, []byte{1, 2}) || b.Offset() != 2 {
+		t.Fatalf("ReadFull() = %v, %v at offset %d, want [1 2] at offset 2", buf, err, b.Offset())
+	}
+
+	if err := b.ReadFull(buf); !errors.Is(err, ErrEndOfFile) || b.Offset() != 2 {
+		t.Fatalf("ReadFull() error = %v at offset %d, want ErrEndOfFile at offset 2", err, b.Offset())
+	}
+}
+
+func TestSubViewBounds(t *testing.T) {
+	b := From([]byte{1, 2, 3, 4})
+	b.SetOffset(1)
+
+	var decodeErr *DecodeError
+	if _, err := b.Sub(-1); !errors.Is(err, ErrInvalidLength) || !errors.As(err, &decodeErr) {
+		t.Fatalf("Sub(-1) error = %v, want a *DecodeError wrapping ErrInvalidLength", err)
+	}
+
+	if _, err := b.Sub(4); !errors.Is(err, ErrEndOfFile) || b.Offset() != 1 {
+		t.Fatalf("Sub(4) at offset 1 error = %v, want ErrEndOfFile without moving the cursor", err)
+	}
+
+	tests := []struct {
+		start, end int
+		err        error
+	}{
+		{-1, 2, ErrInvalidOffset},
+		{3, 2, ErrInvalidOffset},
+		{0, 5, ErrEndOfFile},
+		{4, 5, ErrEndOfFile},
+	}
+
+	for _, tt := range tests {
+		if _, err := b.View(tt.start, tt.end); !errors.Is(err, tt.err) {
+			t.Fatalf("View(%d, %d) error = %v, want %v", tt.start, tt.end, err, tt.err)
+		}
+	}
+
+	// Empty sub-buffers and views at the end of the buffer are valid.
+	if v, err := b.View(4, 4); err != nil || v.Length() != 0 {
+		t.Fatalf("View(4, 4) = %v, want an empty buffer", err)
+	}
+
+	if v, err := b.Sub(3); err != nil || v.Length() != 3 || b.Offset() != 4 {
+		t.Fatalf("Sub(3) at offset 1 error = %v with the cursor at %d, want 4", err, b.Offset())
+	}
+
+	if v, err := b.Sub(0); err != nil || v.Length() != 0 {
+		t.Fatalf("Sub(0) at the end error = %v, want an empty buffer", err)
+	}
+}
+
+func TestSubCursor(t *testing.T) {
+	b := From([]byte{1, 2, 3, 4, 5})
+
+	sub, err := b.Sub(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The parent moves past the sub-buffer, which starts at its own offset zero.
+	if b.Offset() != 2 || sub.Offset() != 0 {
+		t.Fatalf("Sub(2) left the parent at %d and the sub-buffer at %d, want 2 and 0", b.Offset(), sub.Offset())
+	}
+
+	if v, err := b.ReadUint8(); err != nil || v != 3 {
+		t.Fatalf("ReadUint8() after Sub(2) = %d, %v, want 3", v, err)
+	}
+
+	// Reads from the sub-buffer do not move the parent.
+	if _, err := sub.ReadUint8(); err != nil || b.Offset() != 3 {
+		t.Fatalf("ReadUint8() from the sub-buffer moved the parent to %d", b.Offset())
+	}
+
+	// A view does not move the parent at all.
+	if _, err := b.View(0, 5); err != nil || b.Offset() != 3 {
+		t.Fatalf("View() moved the parent to %d", b.Offset())
+	}
+}
+
+func TestViewIsolation(t *testing.T) {
+	b := NewGrowable(16)
+	if _, err := b.Write([]byte{1, 2, 3, 4, 5, 6}); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, v := range []func() (*Buffer, error){
+		func() (*Buffer, error) { return b.View(1, 3) },
+		func() (*Buffer, error) { b.SetOffset(1); return b.Sub(2) },
+	} {
+		view, err := v()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if view.Growable() || view.Capacity() != 2 {
+			t.Fatalf("view has capacity %d and growable %v, want a fixed capacity of 2", view.Capacity(), view.Growable())
+		}
+
+		// Writes within the view reach the parent.
+		if err := view.WriteUint16(0xaabb, byteorder.BigEndian); err != nil || b.Slice()[1] != 0xaa || b.Slice()[2] != 0xbb {
+			t.Fatalf("WriteUint16() through the view error = %v or did not reach the parent", err)
+		}
+
+		// Writes past the view neither grow it nor reach the bytes that follow in the parent.
+		if err := view.WriteUint8(0xff); !errors.Is(err, ErrEndOfFile) || b.Slice()[3] != 4 {
+			t.Fatalf("WriteUint8() past the view error = %v, want ErrEndOfFile without touching the parent", err)
+		}
+
+		view.Reset()
+		if _, err := view.Write([]byte{9, 9, 9}); !errors.Is(err, ErrEndOfFile) || b.Slice()[3] != 4 {
+			t.Fatalf("Write() after Reset() error = %v, want ErrEndOfFile without touching the parent", err)
+		}
+
+		// Appending to the view's slice reallocates instead of overwriting the parent.
+		_ = append(view.Slice(), 0xff)
+		if b.Slice()[3] != 4 {
+			t.Fatal("appending to the view's slice overwrote the parent")
+		}
+
+		b.Slice()[1], b.Slice()[2] = 2, 3
+	}
+}
+
+func TestViewInherits(t *testing.T) {
+	b := From([]byte{0x02, 0x02})
+	b.SetPolicy(LenientPolicy)
+	b.SetInet6Family(Inet6Linux)
+
+	sub, err := b.Sub(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	view, err := b.View(0, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, v := range []*Buffer{sub, view} {
+		if v.Policy() != LenientPolicy || v.Inet6Family() != Inet6Linux {
+			t.Fatalf("view has policy %+v and family %d, want the parent's", v.Policy(), v.Inet6Family())
+		}
+
+		if ok, err := v.ReadBool(); !ok || err != nil {
+			t.Fatalf("ReadBool() of 0x02 through a lenient view = %v, %v, want true", ok, err)
+		}
+	}
+
+	// Changing the view's settings does not affect the parent.
+	view.SetPolicy(StrictPolicy)
+	if b.Policy() != LenientPolicy {
+		t.Fatal("SetPolicy() on a view changed the parent's policy")
+	}
+}
+
+func TestSubDecodeErrorOffset(t *testing.T) {
+	b := From([]byte{1, 2, 3, 4})
+	b.SetOffset(2)
+
+	sub, err := b.Sub(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Offsets are relative to the sub-buffer's start.
+	var decodeErr *DecodeError
+	if _, err := sub.ReadUint32(byteorder.BigEndian); !errors.As(err, &decodeErr) || decodeErr.Offset != 0 || decodeErr.Remaining != 2 {
+		t.Fatalf("ReadUint32() through the sub-buffer error = %v, want a *DecodeError at offset 0 with 2 bytes left", err)
+	}
+}
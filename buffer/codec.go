@@ -0,0 +1,145 @@
+package buffer
+
+import "fmt"
+
+// Encoder is implemented by types that can write themselves into a buffer. The methods generated by binarygen
+// implement it.
+type Encoder interface {
+	MarshalBinaryTo(b *Buffer) error
+}
+
+// Decoder is implemented by types that can read themselves from a buffer. The methods generated by binarygen
+// implement it.
+type Decoder interface {
+	UnmarshalBinaryFrom(b *Buffer) error
+}
+
+// Codec is implemented by types that can both write themselves into and read themselves from a buffer.
+type Codec interface {
+	Encoder
+	Decoder
+}
+
+// PtrDecoder constrains a type parameter to pointers to T that implement Decoder, so that generic helpers can
+// allocate values of T and decode into them.
+type PtrDecoder[T any] interface {
+	*T
+	Decoder
+}
+
+// PtrEncoder constrains a type parameter to pointers to T that implement Encoder, so that generic helpers can
+// encode values of T whose methods have pointer receivers.
+type PtrEncoder[T any] interface {
+	*T
+	Encoder
+}
+
+// Reads a slice of decoders preceded by its element count laid out as described by the provided layout and
+// returns it. Counts larger than the bytes left are rejected before the slice is allocated.
+func ReadSlice[T any, PT PtrDecoder[T]](b *Buffer, l Layout) ([]T, error) {
+	n, err := b.ReadLength(l)
+	if err != nil {
+		return nil, err
+	}
+
+	v := make([]T, n)
+	for i := range v {
+		if err := PT(&v[i]).UnmarshalBinaryFrom(b); err != nil {
+			return nil, WithField(err, fmt.Sprintf("[%d]", i))
+		}
+	}
+
+	return v, nil
+}
+
+// Writes the provided slice of encoders preceded by its element count laid out as described by the provided
+// layout.
+func WriteSlice[T any, PT PtrEncoder[T]](b *Buffer, v []T, l Layout) error {
+	if err := b.WriteLength(len(v), l); err != nil {
+		return err
+	}
+
+	for i := range v {
+		if err := PT(&v[i]).MarshalBinaryTo(b); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Reads an optional decoder preceded by a boolean reporting its presence and returns it, or nil if it was
+// absent.
+func ReadOptional[T any, PT PtrDecoder[T]](b *Buffer) (*T, error) {
+	ok, err := b.ReadBool()
+	if err != nil || !ok {
+		return nil, err
+	}
+
+	v := new(T)
+	if err := PT(v).UnmarshalBinaryFrom(b); err != nil {
+		return nil, err
+	}
+
+	return v, nil
+}
+
+// Writes the provided optional encoder preceded by a boolean reporting whether it is non-nil.
+func WriteOptional[T any, PT PtrEncoder[T]](b *Buffer, v *T) error {
+	if err := b.WriteBool(v != nil); err != nil {
+		return err
+	}
+
+	if v == nil {
+		return nil
+	}
+
+	return PT(v).MarshalBinaryTo(b)
+}
+
+// Reads a map of decoders preceded by its entry count laid out as described by the provided layout and
+// returns it. Each entry is encoded as its key followed by its value, and counts larger than the bytes left
+// are rejected before the map is allocated.
+func ReadMap[K comparable, V any, PK PtrDecoder[K], PV PtrDecoder[V]](b *Buffer, l Layout) (map[K]V, error) {
+	n, err := b.ReadLength(l)
+	if err != nil {
+		return nil, err
+	}
+
+	m := make(map[K]V, n)
+	for i := 0; i < n; i++ {
+		var k K
+		if err := PK(&k).UnmarshalBinaryFrom(b); err != nil {
+			return nil, WithField(err, fmt.Sprintf("[%d]", i))
+		}
+
+		var v V
+		if err := PV(&v).UnmarshalBinaryFrom(b); err != nil {
+			return nil, WithField(err, fmt.Sprintf("[%d]", i))
+		}
+
+		m[k] = v
+	}
+
+	return m, nil
+}
+
+// Writes the provided map of encoders preceded by its entry count laid out as described by the provided
+// layout. Entries are written in the map's iteration order, which is unspecified.
+func WriteMap[K comparable, V any, PK PtrEncoder[K], PV PtrEncoder[V]](b *Buffer, m map[K]V, l Layout) error {
+	if err := b.WriteLength(len(m), l); err != nil {
+		return err
+	}
+
+	for k, v := range m {
+		if err := PK(&k).MarshalBinaryTo(b); err != nil {
+			return err
+		}
+
+		if err := PV(&v).MarshalBinaryTo(b); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,119 @@
+package buffer
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/gamevidea/binary/byteorder"
+)
+
+// testID is a big-endian uint16 implementing Codec
+type testID uint16
+
+func (v *testID) MarshalBinaryTo(b *Buffer) error {
+	return b.WriteUint16(uint16(*v), byteorder.BigEndian)
+}
+
+func (v *testID) UnmarshalBinaryFrom(b *Buffer) error {
+	x, err := b.ReadUint16(byteorder.BigEndian)
+	*v = testID(x)
+	return err
+}
+
+func TestReadSliceOversizedCount(t *testing.T) {
+	// A count of 0x7fffffff followed by a single element must not allocate two billion elements.
+	data := []byte{0xff, 0xff, 0xff, 0xff, 0x07, 0x00, 0x01}
+
+	b := From(data)
+	if v, err := ReadSlice[testID](b, BedrockLayout); !errors.Is(err, ErrEndOfFile) || v != nil || b.Offset() != 0 {
+		t.Fatalf("ReadSlice() = %v, %v at offset %d, want ErrEndOfFile at offset 0", v, err, b.Offset())
+	}
+
+	b = From(data)
+	if m, err := ReadMap[testID, testID](b, BedrockLayout); !errors.Is(err, ErrEndOfFile) || m != nil || b.Offset() != 0 {
+		t.Fatalf("ReadMap() = %v, %v at offset %d, want ErrEndOfFile at offset 0", m, err, b.Offset())
+	}
+
+	allocs := testing.AllocsPerRun(10, func() {
+		ReadSlice[testID](From(data), BedrockLayout)
+	})
+	if allocs > 2 {
+		t.Fatalf("ReadSlice() of an oversized count allocated %v times", allocs)
+	}
+}
+
+func TestSliceRoundTrip(t *testing.T) {
+	for _, v := range [][]testID{{}, {1, 0xbeef, 0xffff}} {
+		b := NewGrowable(0)
+		if err := WriteSlice(b, v, BedrockLayout); err != nil {
+			t.Fatalf("WriteSlice() error = %v", err)
+		}
+
+		if b.Offset() != 1+2*len(v) {
+			t.Fatalf("WriteSlice() wrote %d bytes, want %d", b.Offset(), 1+2*len(v))
+		}
+
+		got, err := ReadSlice[testID](From(b.Bytes()), BedrockLayout)
+		if err != nil || len(got) != len(v) {
+			t.Fatalf("ReadSlice() = %v, %v, want %v", got, err, v)
+		}
+
+		for i := range v {
+			if got[i] != v[i] {
+				t.Fatalf("ReadSlice() = %v, want %v", got, v)
+			}
+		}
+	}
+}
+
+func TestSliceTruncatedElement(t *testing.T) {
+	var decodeErr *DecodeError
+	if _, err := ReadSlice[testID](From([]byte{2, 0x00, 0x01, 0x00}), BedrockLayout); !errors.As(err, &decodeErr) || decodeErr.Field != "[1]" {
+		t.Fatalf("ReadSlice() error = %v, want a *DecodeError of field [1]", err)
+	}
+}
+
+func TestOptionalRoundTrip(t *testing.T) {
+	id := testID(0x1234)
+
+	for _, v := range []*testID{nil, &id} {
+		b := NewGrowable(0)
+		if err := WriteOptional(b, v); err != nil {
+			t.Fatalf("WriteOptional() error = %v", err)
+		}
+
+		got, err := ReadOptional[testID](From(b.Bytes()))
+		if err != nil {
+			t.Fatalf("ReadOptional() error = %v", err)
+		}
+
+		if (got == nil) != (v == nil) || (v != nil && *got != *v) {
+			t.Fatalf("ReadOptional() = %v, want %v", got, v)
+		}
+	}
+
+	b := NewGrowable(0)
+	if err := WriteOptional[testID](b, nil); err != nil || b.Offset() != 1 || b.Bytes()[0] != 0 {
+		t.Fatalf("WriteOptional() of nil = %x, %v, want a single false byte", b.Bytes(), err)
+	}
+}
+
+func TestMapRoundTrip(t *testing.T) {
+	m := map[testID]testID{1: 10, 2: 20, 0xffff: 0}
+
+	b := NewGrowable(0)
+	if err := WriteMap(b, m, BedrockLayout); err != nil {
+		t.Fatalf("WriteMap() error = %v", err)
+	}
+
+	got, err := ReadMap[testID, testID](From(b.Bytes()), BedrockLayout)
+	if err != nil || len(got) != len(m) {
+		t.Fatalf("ReadMap() = %v, %v, want %v", got, err, m)
+	}
+
+	for k, v := range m {
+		if got[k] != v {
+			t.Fatalf("ReadMap() = %v, want %v", got, m)
+		}
+	}
+}
@@ -1,6 +1,9 @@
 package buffer
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+)
 
 // ErrEndOfFile is the error returned when end of file or end of buffer is reached unexpectedly
 // during reading or writing operations.
@@ -13,5 +16,89 @@ var ErrInvalidByteOrder = errors.New("could not parse the byteorder from the pro
 // ErrInvalidBool is the error returned when unknown boolbyte is provided in encoding/decoding of booleans
 var ErrInvalidBool = errors.New("could not parse the boolbyte from the provided byte")
 
+// ErrInvalidAddrVersion is the error returned when an address has an unknown ip version and the buffer's
+// policy is strict for address versions
+var ErrInvalidAddrVersion = errors.New("could not parse the ip version of the address")
+
 // ErrInvalidMagic is the error returned when the magic unconnected sequence could not be parsed
 var ErrInvalidMagic = errors.New("could not parse the magic unconnected message sequence")
+
+// ErrOverlongVarInt is the error returned when a varint uses more bytes than required by its value or
+// overflows the width of the integer it is decoded into
+var ErrOverlongVarInt = errors.New("could not parse the varint as it is overlong or overflows its type")
+
+// ErrInvalidPrefix is the error returned when unknown length prefix is provided in encoding/decoding of strings
+// and byte slices
+var ErrInvalidPrefix = errors.New("could not parse the length prefix from the provided prefix id")
+
+// ErrInvalidLength is the error returned when a length prefix is negative or exceeds the configured maximum length
+var ErrInvalidLength = errors.New("could not complete the operation as the length is negative or exceeds the maximum")
+
+// ErrInvalidUTF8 is the error returned when a string is not valid UTF-8 and validation was requested
+var ErrInvalidUTF8 = errors.New("could not parse the string as it is not valid utf-8")
+
+// ErrInvalidOffset is the error returned when the buffer's cursor would be moved outside of the buffer's bounds
+var ErrInvalidOffset = errors.New("could not move the cursor as the offset is out of the buffer's bounds")
+
+// ErrInvalidWhence is the error returned when unknown whence is provided while seeking
+var ErrInvalidWhence = errors.New("could not seek as the whence is invalid")
+
+// DecodeError is the error returned by the buffer's read operations. It describes where in the buffer decoding
+// failed and wraps one of the sentinel errors above so that errors.Is keeps working.
+type DecodeError struct {
+	// Offset is the buffer's cursor offset at which the failed operation started
+	Offset int
+	// Size is the number of bytes requested by the failed operation
+	Size int
+	// Remaining is the number of bytes that were left to be read at Offset
+	Remaining int
+	// Field is an optional dot separated path of the field that was being decoded
+	Field string
+	// Err is the underlying error that caused the operation to fail
+	Err error
+}
+
+// Returns the string representation of the decode error
+func (e *DecodeError) Error() string {
+	if e.Field != "" {
+		return fmt.Sprintf("%v (field: %s, offset: %d, size: %d, remaining: %d)", e.Err, e.Field, e.Offset, e.Size, e.Remaining)
+	}
+
+	return fmt.Sprintf("%v (offset: %d, size: %d, remaining: %d)", e.Err, e.Offset, e.Size, e.Remaining)
+}
+
+// Returns the underlying error of the decode error
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
+
+// Annotates the provided error with the name of the field that was being decoded and returns it. Nested
+// decoders prepend their field names, so the resulting path reads from the outermost to the innermost field.
+// Errors other than *DecodeError are returned as is.
+func WithField(err error, field string) error {
+	var e *DecodeError
+	if !errors.As(err, &e) {
+		return err
+	}
+
+	switch {
+	case e.Field == "":
+		e.Field = field
+	case e.Field[0] == '[':
+		e.Field = field + e.Field
+	default:
+		e.Field = field + "." + e.Field
+	}
+
+	return err
+}
+
+// Returns a decode error for an operation that started at the provided offset and requested n bytes
+func (b *Buffer) decodeError(offset int, n int, err error) error {
+	return &DecodeError{
+		Offset:    offset,
+		Size:      n,
+		Remaining: b.len - offset,
+		Err:       err,
+	}
+}
@@ -0,0 +1,150 @@
+package buffer
+
+import "io"
+
+var (
+	_ io.Reader      = (*Buffer)(nil)
+	_ io.Writer      = (*Buffer)(nil)
+	_ io.ByteReader  = (*Buffer)(nil)
+	_ io.ByteWriter  = (*Buffer)(nil)
+	_ io.ByteScanner = (*Buffer)(nil)
+	_ io.ReaderFrom  = (*Buffer)(nil)
+	_ io.WriterTo    = (*Buffer)(nil)
+	_ io.Seeker      = (*Buffer)(nil)
+)
+
+// minReadFrom is the minimum number of bytes a growable buffer makes room for on every read in ReadFrom
+const minReadFrom = 512
+
+// Reads a single byte from the buffer and returns it. It returns io.EOF once no bytes are left to be read,
+// implementing io.ByteReader.
+func (b *Buffer) ReadByte() (byte, error) {
+	if b.len-b.offset < 1 {
+		return 0, io.EOF
+	}
+
+	v := b.slice[b.offset]
+	b.offset += 1
+
+	return v, nil
+}
+
+// Unreads the last byte read from the buffer by moving the cursor back by one, implementing io.ByteScanner.
+func (b *Buffer) UnreadByte() error {
+	if b.offset < 1 {
+		return ErrInvalidOffset
+	}
+
+	b.offset -= 1
+	return nil
+}
+
+// Writes a single byte to the buffer, implementing io.ByteWriter.
+func (b *Buffer) WriteByte(v byte) error {
+	return b.WriteUint8(v)
+}
+
+// Reads from the provided reader until io.EOF is reached and writes the data to the buffer, returning the
+// number of bytes read. Growable buffers grow as needed and their length ends at the last byte read once they
+// grew. Fixed buffers return ErrEndOfFile once they are full and the reader may have more data than the buffer
+// can hold. Readers implementing io.ByteScanner are probed for data left without losing it, while other
+// readers are not read any further, so that no byte is consumed that the buffer cannot hold. It implements
+// io.ReaderFrom.
+func (b *Buffer) ReadFrom(r io.Reader) (int64, error) {
+	var total int64
+
+	for {
+		grew := b.len-b.offset < 1 && b.writable(minReadFrom)
+		if !grew && b.len-b.offset < 1 {
+			s, ok := r.(io.ByteScanner)
+			if !ok {
+				return total, ErrEndOfFile
+			}
+
+			// The buffer is full, make sure that the reader has no data left that we are unable to hold.
+			if _, err := s.ReadByte(); err != nil {
+				if err == io.EOF {
+					return total, nil
+				}
+
+				return total, err
+			}
+
+			if err := s.UnreadByte(); err != nil {
+				return total, err
+			}
+
+			return total, ErrEndOfFile
+		}
+
+		n, err := r.Read(b.slice[b.offset:b.len])
+		if n < 0 {
+			return total, io.ErrNoProgress
+		}
+
+		b.offset += n
+		total += int64(n)
+
+		// The room made for the read is not part of the buffer's contents beyond the bytes read into it.
+		if grew {
+			b.len = b.offset
+		}
+
+		if err == io.EOF {
+			return total, nil
+		}
+		if err != nil {
+			return total, err
+		}
+	}
+}
+
+// Writes the bytes left to be read in the buffer to the provided writer and advances the cursor by the
+// number of bytes written, implementing io.WriterTo.
+func (b *Buffer) WriteTo(w io.Writer) (int64, error) {
+	l := b.len - b.offset
+	if l < 1 {
+		return 0, nil
+	}
+
+	n, err := w.Write(b.slice[b.offset:b.len])
+	if n > l {
+		panic("buffer: invalid write count returned by io.Writer")
+	}
+
+	b.offset += n
+	if err != nil {
+		return int64(n), err
+	}
+
+	if n != l {
+		return int64(n), io.ErrShortWrite
+	}
+
+	return int64(n), nil
+}
+
+// Sets the buffer's internal cursor offset relative to the start of the buffer, the current offset or the
+// buffer's length depending on whence, implementing io.Seeker. Offsets outside of the buffer's length are
+// rejected with ErrInvalidOffset.
+func (b *Buffer) Seek(offset int64, whence int) (int64, error) {
+	var abs int64
+
+	switch whence {
+	case io.SeekStart:
+		abs = offset
+	case io.SeekCurrent:
+		abs = int64(b.offset) + offset
+	case io.SeekEnd:
+		abs = int64(b.len) + offset
+	default:
+		return 0, ErrInvalidWhence
+	}
+
+	if abs < 0 || abs > int64(b.len) {
+		return 0, ErrInvalidOffset
+	}
+
+	b.offset = int(abs)
+	return abs, nil
+}
@@ -0,0 +1,89 @@
+package buffer
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestReadFromGrowable(t *testing.T) {
+	data := bytes.Repeat([]byte("binary"), 500)
+
+	b := NewGrowable(0)
+	n, err := b.ReadFrom(iotest.OneByteReader(bytes.NewReader(data)))
+	if err != nil || n != int64(len(data)) || !bytes.Equal(b.Bytes(), data) {
+		t.Fatalf("ReadFrom() = %d, %v, want %d bytes read", n, err, len(data))
+	}
+}
+
+func TestReadFromGrowableLength(t *testing.T) {
+	b := NewGrowable(0)
+	if _, err := b.ReadFrom(strings.NewReader("abc")); err != nil {
+		t.Fatalf("ReadFrom() error = %v", err)
+	}
+
+	// The room made for reading is trimmed, so that no padding is left to be read after the data.
+	if b.Offset() != 3 || b.Length() != 3 || b.Remaining() != 0 {
+		t.Fatalf("ReadFrom() left offset %d and length %d, want 3 and 3", b.Offset(), b.Length())
+	}
+
+	b.Resize(b.Offset())
+	if _, err := b.ReadFrom(strings.NewReader("def")); err != nil || string(b.Bytes()) != "abcdef" || b.Length() != 6 {
+		t.Fatalf("ReadFrom() appended %q with length %d, %v", b.Bytes(), b.Length(), err)
+	}
+}
+
+func TestReadFromFixedExactFit(t *testing.T) {
+	b := New(4)
+
+	n, err := b.ReadFrom(bytes.NewReader([]byte{1, 2, 3, 4}))
+	if err != nil || n != 4 {
+		t.Fatalf("ReadFrom() = %d, %v, want 4 bytes read", n, err)
+	}
+}
+
+func TestReadFromFixedKeepsDataLeft(t *testing.T) {
+	r := strings.NewReader("abcdef")
+
+	b := New(4)
+	n, err := b.ReadFrom(r)
+	if !errors.Is(err, ErrEndOfFile) || n != 4 {
+		t.Fatalf("ReadFrom() = %d, %v, want 4 bytes read and ErrEndOfFile", n, err)
+	}
+
+	// The byte used to probe the reader for data left is not lost.
+	left, _ := io.ReadAll(r)
+	if string(left) != "ef" {
+		t.Fatalf("reader has %q left, want %q", left, "ef")
+	}
+}
+
+func TestReadFromFixedDoesNotProbePlainReaders(t *testing.T) {
+	r := strings.NewReader("abcdef")
+
+	b := New(4)
+	n, err := b.ReadFrom(io.LimitReader(r, 6))
+	if !errors.Is(err, ErrEndOfFile) || n != 4 {
+		t.Fatalf("ReadFrom() = %d, %v, want 4 bytes read and ErrEndOfFile", n, err)
+	}
+
+	left, _ := io.ReadAll(r)
+	if string(left) != "ef" {
+		t.Fatalf("reader has %q left, want %q", left, "ef")
+	}
+}
+
+func TestSeek(t *testing.T) {
+	b := From([]byte{1, 2, 3, 4})
+
+	if off, err := b.Seek(-1, io.SeekEnd); err != nil || off != 3 {
+		t.Fatalf("Seek(-1, io.SeekEnd) = %d, %v, want 3", off, err)
+	}
+
+	if _, err := b.Seek(5, io.SeekStart); !errors.Is(err, ErrInvalidOffset) || b.Offset() != 3 {
+		t.Fatalf("Seek(5, io.SeekStart) error = %v at offset %d, want ErrInvalidOffset at 3", err, b.Offset())
+	}
+}
@@ -0,0 +1,46 @@
+package buffer
+
+// Mark is a checkpoint of the buffer's cursor and length that the buffer can be rewound to
+type Mark struct {
+	offset int
+	len    int
+	epoch  uint32
+}
+
+// Returns a checkpoint of the buffer's current cursor and length
+func (b *Buffer) Mark() Mark {
+	return Mark{offset: b.offset, len: b.len, epoch: b.epoch}
+}
+
+// Rewinds the buffer's cursor and length to the provided checkpoint, undoing the reads, writes and resizes
+// that happened since it was taken. Bytes written after the checkpoint are left in the internal slice but
+// are no longer part of the buffer's contents. It returns ErrInvalidOffset if the checkpoint is outside of
+// the buffer's capacity, such as one taken from another buffer, or was taken before the buffer was returned
+// to its pool.
+func (b *Buffer) Rewind(m Mark) error {
+	if m.offset < 0 || m.offset > m.len || m.len > b.cap || m.epoch != b.epoch || b.released {
+		return ErrInvalidOffset
+	}
+
+	b.offset = m.offset
+	b.len = m.len
+
+	return nil
+}
+
+// Runs the provided function as a transaction and rewinds the buffer to where it was if it returns an
+// error, so that a group of writes either completes as a whole or leaves nothing partially written behind.
+// It equally serves speculative decoding, such as trying a newer layout of a packet before falling back to
+// an older one. The function's error is returned as is.
+func (b *Buffer) Transaction(fn func(b *Buffer) error) error {
+	m := b.Mark()
+
+	if err := fn(b); err != nil {
+		b.offset = m.offset
+		b.len = m.len
+
+		return err
+	}
+
+	return nil
+}
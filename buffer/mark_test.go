@@ -0,0 +1,80 @@
+package buffer
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestTransactionRollback(t *testing.T) {
+	errFailed := errors.New("failed")
+
+	for _, b := range []*Buffer{NewGrowable(0), New(64)} {
+		if err := b.WriteUint8(0x01); err != nil {
+			t.Fatal(err)
+		}
+		offset, length := b.Offset(), b.Length()
+
+		err := b.Transaction(func(b *Buffer) error {
+			if err := b.WriteVarUint32(0xffffffff); err != nil {
+				return err
+			}
+
+			b.Resize(b.Offset())
+			return errFailed
+		})
+
+		// The callback's error is returned as is and the writes and resizes are undone.
+		if err != errFailed {
+			t.Fatalf("Transaction() error = %v, want the callback's error", err)
+		}
+
+		if b.Offset() != offset || b.Length() != length {
+			t.Fatalf("Transaction() rolled back to offset %d and length %d, want %d and %d", b.Offset(), b.Length(), offset, length)
+		}
+	}
+}
+
+func TestTransactionCommit(t *testing.T) {
+	b := NewGrowable(0)
+
+	err := b.Transaction(func(b *Buffer) error {
+		return b.WriteUint8(0x01)
+	})
+
+	if err != nil || b.Offset() != 1 || b.Bytes()[0] != 0x01 {
+		t.Fatalf("Transaction() = %v with the cursor at %d, want the write kept", err, b.Offset())
+	}
+}
+
+func TestTransactionSpeculativeRead(t *testing.T) {
+	b := From([]byte{0x02, 0x01})
+
+	// A failed read in the transaction leaves the cursor where it was for a fallback read.
+	err := b.Transaction(func(b *Buffer) error {
+		if _, err := b.ReadUint8(); err != nil {
+			return err
+		}
+
+		_, err := b.ReadBool()
+		if err == nil {
+			_, err = b.ReadBool()
+		}
+
+		return err
+	})
+
+	if !errors.Is(err, ErrEndOfFile) || b.Offset() != 0 {
+		t.Fatalf("Transaction() = %v with the cursor at %d, want ErrEndOfFile at 0", err, b.Offset())
+	}
+}
+
+func TestRewindOtherBuffer(t *testing.T) {
+	large := New(64)
+	large.SetOffset(32)
+
+	// A mark beyond the buffer's capacity, such as one taken from a larger buffer, is rejected.
+	b := New(8)
+	if err := b.Rewind(large.Mark()); !errors.Is(err, ErrInvalidOffset) || b.Offset() != 0 {
+		t.Fatalf("Rewind() to a mark of a larger buffer error = %v, want ErrInvalidOffset", err)
+	}
+}
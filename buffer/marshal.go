@@ -0,0 +1,628 @@
+package buffer
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"reflect"
+	"sync"
+
+	"github.com/gamevidea/binary/internal/bintag"
+)
+
+// ErrUnsupportedType is the error returned when a value of a type that has no wire encoding is marshaled or
+// unmarshaled, or when a struct tag does not fit the type of its field.
+var ErrUnsupportedType = errors.New("could not encode or decode the value as its type is not supported")
+
+// ErrLengthMismatch is the error returned when the length of a slice does not match the field holding its
+// element count while marshaling.
+var ErrLengthMismatch = errors.New("could not encode the slice as its length does not match its count field")
+
+// coder encodes and decodes a single value of a specific type
+type coder struct {
+	encode func(b *Buffer, v reflect.Value) error
+	decode func(b *Buffer, v reflect.Value) error
+}
+
+// countedCoder encodes and decodes a slice whose element count is held by another field of the struct
+type countedCoder struct {
+	encode func(b *Buffer, v reflect.Value) error
+	decode func(b *Buffer, v reflect.Value, n int) error
+}
+
+// field is a single encoded field of a struct
+type field struct {
+	name  string
+	index int
+
+	// count is the index of the field holding the element count of this field or -1 if the field has
+	// none.
+	count int
+
+	coder   coder
+	counted countedCoder
+}
+
+// structCodec encodes and decodes all fields of a struct in their declaration order
+type structCodec struct {
+	fields []field
+}
+
+// codecEntry is a cached result of compiling the codec of a struct type
+type codecEntry struct {
+	codec *structCodec
+	err   error
+}
+
+// codecs caches the compiled codec of every struct type that has been marshaled or unmarshaled
+var codecs sync.Map
+
+// addrType is the type of the raknet socket addresses encoded by the addr kind
+var addrType = reflect.TypeOf(net.UDPAddr{})
+
+// codecType is the type of the Codec interface implemented by types that encode themselves
+var codecType = reflect.TypeOf((*Codec)(nil)).Elem()
+
+// Encodes the provided struct, or pointer to struct, into a new byte slice as described by the `bin` tags of
+// its fields and returns it. Fields whose pointer implements Codec are encoded by their own methods.
+func Marshal(v any) ([]byte, error) {
+	b := NewGrowable(minGrowableCapacity)
+	if err := MarshalTo(b, v); err != nil {
+		return nil, err
+	}
+
+	return b.Bytes(), nil
+}
+
+// Encodes the provided struct, or pointer to struct, into the buffer as described by the `bin` tags of its
+// fields.
+func MarshalTo(b *Buffer, v any) error {
+	rv := reflect.Indirect(reflect.ValueOf(v))
+	if rv.Kind() != reflect.Struct {
+		return fmt.Errorf("%w: %T", ErrUnsupportedType, v)
+	}
+
+	// Byte arrays and addresses are accessed through their address, copy values that were passed by value.
+	if !rv.CanAddr() {
+		p := reflect.New(rv.Type())
+		p.Elem().Set(rv)
+		rv = p.Elem()
+	}
+
+	c, err := codecOf(rv.Type())
+	if err != nil {
+		return err
+	}
+
+	return c.encode(b, rv)
+}
+
+// Decodes the buffer into the struct pointed to by v as described by the `bin` tags of its fields.
+func Unmarshal(b *Buffer, v any) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("%w: %T", ErrUnsupportedType, v)
+	}
+
+	c, err := codecOf(rv.Elem().Type())
+	if err != nil {
+		return err
+	}
+
+	return c.decode(b, rv.Elem())
+}
+
+// Returns the cached codec of the provided struct type, compiling it on first use
+func codecOf(t reflect.Type) (*structCodec, error) {
+	if e, ok := codecs.Load(t); ok {
+		entry := e.(codecEntry)
+		return entry.codec, entry.err
+	}
+
+	c, err := compileStruct(t)
+	e, _ := codecs.LoadOrStore(t, codecEntry{codec: c, err: err})
+
+	entry := e.(codecEntry)
+	return entry.codec, entry.err
+}
+
+// Encodes all fields of the provided struct value
+func (c *structCodec) encode(b *Buffer, v reflect.Value) error {
+	for i := range c.fields {
+		f := &c.fields[i]
+		fv := v.Field(f.index)
+
+		var err error
+		if f.count >= 0 {
+			if n := countOf(v.Field(f.count)); n != fv.Len() {
+				err = fmt.Errorf("%w: %d elements, count %d", ErrLengthMismatch, fv.Len(), n)
+			} else {
+				err = f.counted.encode(b, fv)
+			}
+		} else {
+			err = f.coder.encode(b, fv)
+		}
+
+		if err != nil {
+			return fmt.Errorf("%s: %w", f.name, err)
+		}
+	}
+
+	return nil
+}
+
+// Decodes all fields of the provided struct value
+func (c *structCodec) decode(b *Buffer, v reflect.Value) error {
+	for i := range c.fields {
+		f := &c.fields[i]
+		fv := v.Field(f.index)
+
+		var err error
+		if f.count >= 0 {
+			err = f.counted.decode(b, fv, countOf(v.Field(f.count)))
+		} else {
+			err = f.coder.decode(b, fv)
+		}
+
+		if err != nil {
+			return WithField(err, f.name)
+		}
+	}
+
+	return nil
+}
+
+// Returns the value of an integer field holding the element count of another field
+func countOf(v reflect.Value) int {
+	if v.CanInt() {
+		return int(v.Int())
+	}
+
+	return int(v.Uint())
+}
+
+// Compiles the codec of the provided struct type
+func compileStruct(t reflect.Type) (*structCodec, error) {
+	c := &structCodec{}
+	indices := make(map[string]int)
+
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if !sf.IsExported() {
+			continue
+		}
+
+		tag, err := bintag.Parse(sf.Tag.Get(bintag.Name))
+		if err != nil {
+			return nil, fmt.Errorf("%s.%s: %w", t, sf.Name, err)
+		}
+
+		if tag.Skip {
+			continue
+		}
+
+		f := field{name: sf.Name, index: i, count: -1}
+
+		if tag.Len != "" {
+			idx, ok := indices[tag.Len]
+			if !ok {
+				return nil, fmt.Errorf("%s.%s: %w: len field %q must be an integer field declared before", t, sf.Name, bintag.ErrInvalidTag, tag.Len)
+			}
+
+			f.count = idx
+			f.counted, err = compileCounted(sf.Type, tag)
+		} else {
+			f.coder, err = compile(sf.Type, tag)
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("%s.%s: %w", t, sf.Name, err)
+		}
+
+		if k := sf.Type.Kind(); k >= reflect.Int && k <= reflect.Uint64 && k != reflect.Uintptr {
+			indices[sf.Name] = i
+		}
+
+		c.fields = append(c.fields, f)
+	}
+
+	return c, nil
+}
+
+// Returns the layout of length prefixed values described by the provided tag
+func layoutOf(tag bintag.Tag) Layout {
+	l := Layout{MaxLength: tag.Max, ValidateUTF8: tag.UTF8}
+
+	switch tag.Prefix {
+	case bintag.PrefixUint16LE:
+		l.Prefix = PrefixUint16LE
+	case bintag.PrefixUint16BE:
+		l.Prefix = PrefixUint16BE
+	case bintag.PrefixInt32LE:
+		l.Prefix = PrefixInt32LE
+	default:
+		l.Prefix = PrefixVarUint32
+	}
+
+	return l
+}
+
+// Compiles the coder of a value of the provided type as described by the tag
+func compile(t reflect.Type, tag bintag.Tag) (coder, error) {
+	switch {
+	case tag.Kind == bintag.KindNone && reflect.PointerTo(t).Implements(codecType):
+		return coder{
+			encode: func(b *Buffer, v reflect.Value) error {
+				return v.Addr().Interface().(Encoder).MarshalBinaryTo(b)
+			},
+			decode: func(b *Buffer, v reflect.Value) error {
+				return v.Addr().Interface().(Decoder).UnmarshalBinaryFrom(b)
+			},
+		}, nil
+	case t == addrType:
+		if tag.Kind != bintag.KindNone && tag.Kind != bintag.KindAddr {
+			break
+		}
+
+		return coder{
+			encode: func(b *Buffer, v reflect.Value) error {
+				addr := v.Addr().Interface().(*net.UDPAddr)
+				return b.WriteAddr(addr)
+			},
+			decode: func(b *Buffer, v reflect.Value) error {
+				addr := v.Addr().Interface().(*net.UDPAddr)
+				return b.ReadAddr(addr)
+			},
+		}, nil
+	case t.Kind() == reflect.Struct:
+		if tag.Kind != bintag.KindNone {
+			break
+		}
+
+		return coder{
+			encode: func(b *Buffer, v reflect.Value) error {
+				c, err := codecOf(t)
+				if err != nil {
+					return err
+				}
+
+				return c.encode(b, v)
+			},
+			decode: func(b *Buffer, v reflect.Value) error {
+				c, err := codecOf(t)
+				if err != nil {
+					return err
+				}
+
+				return c.decode(b, v)
+			},
+		}, nil
+	case t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8 && (tag.Kind == bintag.KindNone || tag.Kind == bintag.KindBytes):
+		l := layoutOf(tag)
+
+		return coder{
+			encode: func(b *Buffer, v reflect.Value) error {
+				return b.WriteByteSlice(v.Bytes(), l)
+			},
+			decode: func(b *Buffer, v reflect.Value) error {
+				slice, err := b.ReadByteSlice(l)
+				if err != nil {
+					return err
+				}
+
+				v.SetBytes(append([]byte(nil), slice...))
+				return nil
+			},
+		}, nil
+	case t.Kind() == reflect.Array && t.Elem().Kind() == reflect.Uint8 && (tag.Kind == bintag.KindNone || tag.Kind == bintag.KindBytes):
+		return coder{
+			encode: func(b *Buffer, v reflect.Value) error {
+				_, err := b.Write(v.Bytes())
+				return err
+			},
+			decode: func(b *Buffer, v reflect.Value) error {
+				return b.ReadFull(v.Bytes())
+			},
+		}, nil
+	case t.Kind() == reflect.Slice:
+		l := layoutOf(tag)
+
+		elem, err := compile(t.Elem(), tag)
+		if err != nil {
+			return coder{}, err
+		}
+
+		return coder{
+			encode: func(b *Buffer, v reflect.Value) error {
+				if err := b.WriteLength(v.Len(), l); err != nil {
+					return err
+				}
+
+				return encodeElements(b, v, elem)
+			},
+			decode: func(b *Buffer, v reflect.Value) error {
+				n, err := b.ReadLength(l)
+				if err != nil {
+					return err
+				}
+
+				v.Set(reflect.MakeSlice(t, n, n))
+				return decodeElements(b, v, elem)
+			},
+		}, nil
+	case t.Kind() == reflect.Array:
+		elem, err := compile(t.Elem(), tag)
+		if err != nil {
+			return coder{}, err
+		}
+
+		return coder{
+			encode: func(b *Buffer, v reflect.Value) error {
+				return encodeElements(b, v, elem)
+			},
+			decode: func(b *Buffer, v reflect.Value) error {
+				return decodeElements(b, v, elem)
+			},
+		}, nil
+	default:
+		return compileBasic(t, tag)
+	}
+
+	return coder{}, fmt.Errorf("%w: %s as %q", ErrUnsupportedType, t, tag.Kind)
+}
+
+// Compiles the coder of a slice whose element count is held by another field of the struct
+func compileCounted(t reflect.Type, tag bintag.Tag) (countedCoder, error) {
+	if t.Kind() != reflect.Slice {
+		return countedCoder{}, fmt.Errorf("%w: len option on %s", ErrUnsupportedType, t)
+	}
+
+	var elem coder
+	if t.Elem().Kind() != reflect.Uint8 || (tag.Kind != bintag.KindNone && tag.Kind != bintag.KindBytes) {
+		var err error
+		if elem, err = compile(t.Elem(), tag); err != nil {
+			return countedCoder{}, err
+		}
+	}
+
+	return countedCoder{
+		encode: func(b *Buffer, v reflect.Value) error {
+			if elem.encode == nil {
+				_, err := b.Write(v.Bytes())
+				return err
+			}
+
+			return encodeElements(b, v, elem)
+		},
+		decode: func(b *Buffer, v reflect.Value, n int) error {
+			if n < 0 || (tag.Max > 0 && n > tag.Max) {
+				return b.decodeError(b.offset, 0, ErrInvalidLength)
+			}
+
+			if n > b.len-b.offset {
+				return b.decodeError(b.offset, n, ErrEndOfFile)
+			}
+
+			v.Set(reflect.MakeSlice(t, n, n))
+			if elem.decode == nil {
+				return b.ReadFull(v.Bytes())
+			}
+
+			return decodeElements(b, v, elem)
+		},
+	}, nil
+}
+
+// Encodes every element of the provided slice or array
+func encodeElements(b *Buffer, v reflect.Value, elem coder) error {
+	for i := 0; i < v.Len(); i++ {
+		if err := elem.encode(b, v.Index(i)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Decodes every element of the provided slice or array
+func decodeElements(b *Buffer, v reflect.Value, elem coder) error {
+	for i := 0; i < v.Len(); i++ {
+		if err := elem.decode(b, v.Index(i)); err != nil {
+			return WithField(err, fmt.Sprintf("[%d]", i))
+		}
+	}
+
+	return nil
+}
+
+// Compiles the coder of a boolean, number or string
+func compileBasic(t reflect.Type, tag bintag.Tag) (coder, error) {
+	kind := tag.Kind
+	if kind == bintag.KindNone {
+		kind = bintag.Infer(t.Kind().String())
+	}
+
+	unsupported := fmt.Errorf("%w: %s as %q", ErrUnsupportedType, t, kind)
+
+	switch k := t.Kind(); {
+	case kind == bintag.KindBool && k == reflect.Bool:
+		return coder{
+			encode: func(b *Buffer, v reflect.Value) error { return b.WriteBool(v.Bool()) },
+			decode: func(b *Buffer, v reflect.Value) error {
+				x, err := b.ReadBool()
+				v.SetBool(x)
+				return err
+			},
+		}, nil
+	case kind == bintag.KindString && k == reflect.String:
+		l := layoutOf(tag)
+
+		return coder{
+			encode: func(b *Buffer, v reflect.Value) error { return b.WriteString(v.String(), l) },
+			decode: func(b *Buffer, v reflect.Value) error {
+				x, err := b.ReadString(l)
+				v.SetString(x)
+				return err
+			},
+		}, nil
+	case k < reflect.Int || k > reflect.Float64 || k == reflect.Uintptr:
+		return coder{}, unsupported
+	}
+
+	// Platform sized integers are treated as 64 bits wide so that the encoding does not depend on the
+	// architecture, matching the code generated by binarygen.
+	bits := t.Bits()
+	if k := t.Kind(); k == reflect.Int || k == reflect.Uint {
+		bits = 64
+	}
+
+	kind = kind.Resolve(bits)
+	switch {
+	case kind.Unsigned() && (t.Kind() < reflect.Uint || t.Kind() > reflect.Uint64),
+		kind.Signed() && (t.Kind() < reflect.Int || t.Kind() > reflect.Int64),
+		kind.Float() && t.Kind() != reflect.Float32 && t.Kind() != reflect.Float64,
+		kind.Bits() == 0 || kind.Bits() > bits:
+		return coder{}, unsupported
+	}
+
+	e := tag.Endian
+	switch kind {
+	case bintag.KindUint8:
+		return coder{
+			encode: func(b *Buffer, v reflect.Value) error { return b.WriteUint8(uint8(v.Uint())) },
+			decode: func(b *Buffer, v reflect.Value) error {
+				x, err := b.ReadUint8()
+				v.SetUint(uint64(x))
+				return err
+			},
+		}, nil
+	case bintag.KindInt8:
+		return coder{
+			encode: func(b *Buffer, v reflect.Value) error { return b.WriteInt8(int8(v.Int())) },
+			decode: func(b *Buffer, v reflect.Value) error {
+				x, err := b.ReadInt8()
+				v.SetInt(int64(x))
+				return err
+			},
+		}, nil
+	case bintag.KindUint16:
+		return coder{
+			encode: func(b *Buffer, v reflect.Value) error { return b.WriteUint16(uint16(v.Uint()), e) },
+			decode: func(b *Buffer, v reflect.Value) error {
+				x, err := b.ReadUint16(e)
+				v.SetUint(uint64(x))
+				return err
+			},
+		}, nil
+	case bintag.KindInt16:
+		return coder{
+			encode: func(b *Buffer, v reflect.Value) error { return b.WriteInt16(int16(v.Int()), e) },
+			decode: func(b *Buffer, v reflect.Value) error {
+				x, err := b.ReadInt16(e)
+				v.SetInt(int64(x))
+				return err
+			},
+		}, nil
+	case bintag.KindUint24:
+		return coder{
+			encode: func(b *Buffer, v reflect.Value) error { return b.WriteUint24(uint32(v.Uint()), e) },
+			decode: func(b *Buffer, v reflect.Value) error {
+				x, err := b.ReadUint24(e)
+				v.SetUint(uint64(x))
+				return err
+			},
+		}, nil
+	case bintag.KindUint32:
+		return coder{
+			encode: func(b *Buffer, v reflect.Value) error { return b.WriteUint32(uint32(v.Uint()), e) },
+			decode: func(b *Buffer, v reflect.Value) error {
+				x, err := b.ReadUint32(e)
+				v.SetUint(uint64(x))
+				return err
+			},
+		}, nil
+	case bintag.KindInt32:
+		return coder{
+			encode: func(b *Buffer, v reflect.Value) error { return b.WriteInt32(int32(v.Int()), e) },
+			decode: func(b *Buffer, v reflect.Value) error {
+				x, err := b.ReadInt32(e)
+				v.SetInt(int64(x))
+				return err
+			},
+		}, nil
+	case bintag.KindUint64:
+		return coder{
+			encode: func(b *Buffer, v reflect.Value) error { return b.WriteUint64(v.Uint(), e) },
+			decode: func(b *Buffer, v reflect.Value) error {
+				x, err := b.ReadUint64(e)
+				v.SetUint(x)
+				return err
+			},
+		}, nil
+	case bintag.KindInt64:
+		return coder{
+			encode: func(b *Buffer, v reflect.Value) error { return b.WriteInt64(v.Int(), e) },
+			decode: func(b *Buffer, v reflect.Value) error {
+				x, err := b.ReadInt64(e)
+				v.SetInt(x)
+				return err
+			},
+		}, nil
+	case bintag.KindFloat32:
+		return coder{
+			encode: func(b *Buffer, v reflect.Value) error { return b.WriteFloat32(float32(v.Float()), e) },
+			decode: func(b *Buffer, v reflect.Value) error {
+				x, err := b.ReadFloat32(e)
+				v.SetFloat(float64(x))
+				return err
+			},
+		}, nil
+	case bintag.KindFloat64:
+		return coder{
+			encode: func(b *Buffer, v reflect.Value) error { return b.WriteFloat64(v.Float(), e) },
+			decode: func(b *Buffer, v reflect.Value) error {
+				x, err := b.ReadFloat64(e)
+				v.SetFloat(x)
+				return err
+			},
+		}, nil
+	case bintag.KindVarUint32:
+		return coder{
+			encode: func(b *Buffer, v reflect.Value) error { return b.WriteVarUint32(uint32(v.Uint())) },
+			decode: func(b *Buffer, v reflect.Value) error {
+				x, err := b.ReadVarUint32()
+				v.SetUint(uint64(x))
+				return err
+			},
+		}, nil
+	case bintag.KindVarInt32:
+		return coder{
+			encode: func(b *Buffer, v reflect.Value) error { return b.WriteVarInt32(int32(v.Int())) },
+			decode: func(b *Buffer, v reflect.Value) error {
+				x, err := b.ReadVarInt32()
+				v.SetInt(int64(x))
+				return err
+			},
+		}, nil
+	case bintag.KindVarUint64:
+		return coder{
+			encode: func(b *Buffer, v reflect.Value) error { return b.WriteVarUint64(v.Uint()) },
+			decode: func(b *Buffer, v reflect.Value) error {
+				x, err := b.ReadVarUint64()
+				v.SetUint(x)
+				return err
+			},
+		}, nil
+	case bintag.KindVarInt64:
+		return coder{
+			encode: func(b *Buffer, v reflect.Value) error { return b.WriteVarInt64(v.Int()) },
+			decode: func(b *Buffer, v reflect.Value) error {
+				x, err := b.ReadVarInt64()
+				v.SetInt(x)
+				return err
+			},
+		}, nil
+	}
+
+	return coder{}, unsupported
+}
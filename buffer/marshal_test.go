@@ -0,0 +1,36 @@
+package buffer
+
+import (
+	"bytes"
+	"math"
+	"testing"
+)
+
+type platformInts struct {
+	Signed   int  `bin:"varint"`
+	Unsigned uint `bin:"varuint"`
+}
+
+func TestMarshalPlatformIntegers(t *testing.T) {
+	signed, unsigned := int64(math.MinInt32-1), uint64(math.MaxUint32+1)
+	v := platformInts{Signed: int(signed), Unsigned: uint(unsigned)}
+
+	got, err := Marshal(&v)
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+
+	// int and uint always encode as 64-bit varints regardless of the architecture.
+	want := NewGrowable(0)
+	want.WriteVarInt64(int64(v.Signed))
+	want.WriteVarUint64(uint64(v.Unsigned))
+
+	if !bytes.Equal(got, want.Bytes()) {
+		t.Fatalf("Marshal() = %x, want %x", got, want.Bytes())
+	}
+
+	var decoded platformInts
+	if err := Unmarshal(From(got), &decoded); err != nil || decoded != v {
+		t.Fatalf("Unmarshal() = %+v, %v, want %+v", decoded, err, v)
+	}
+}
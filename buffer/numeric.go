@@ -9,7 +9,7 @@ import (
 // Reads an unsigned byte and returns it
 func (b *Buffer) ReadUint8() (v uint8, err error) {
 	if b.len-b.offset < 1 {
-		return 0, ErrEndOfFile
+		return 0, b.decodeError(b.offset, 1, ErrEndOfFile)
 	}
 
 	v = b.slice[b.offset]
@@ -20,7 +20,7 @@ func (b *Buffer) ReadUint8() (v uint8, err error) {
 
 // Writes an unsigned byte
 func (b *Buffer) WriteUint8(v uint8) error {
-	if b.len-b.offset < 1 {
+	if !b.writable(1) {
 		return ErrEndOfFile
 	}
 
@@ -33,7 +33,7 @@ func (b *Buffer) WriteUint8(v uint8) error {
 // Reads a signed byte and returns it
 func (b *Buffer) ReadInt8() (v int8, err error) {
 	if b.len-b.offset < 1 {
-		return 0, ErrEndOfFile
+		return 0, b.decodeError(b.offset, 1, ErrEndOfFile)
 	}
 
 	v = int8(b.slice[b.offset])
@@ -44,7 +44,7 @@ func (b *Buffer) ReadInt8() (v int8, err error) {
 
 // Writes a signed byte
 func (b *Buffer) WriteInt8(v int8) error {
-	if b.len-b.offset < 1 {
+	if !b.writable(1) {
 		return ErrEndOfFile
 	}
 
@@ -57,7 +57,7 @@ func (b *Buffer) WriteInt8(v int8) error {
 // Reads an unsigned short and returns it
 func (b *Buffer) ReadUint16(e byteorder.Endian) (v uint16, err error) {
 	if b.len-b.offset < 2 {
-		return 0, ErrEndOfFile
+		return 0, b.decodeError(b.offset, 2, ErrEndOfFile)
 	}
 
 	switch e {
@@ -66,7 +66,7 @@ func (b *Buffer) ReadUint16(e byteorder.Endian) (v uint16, err error) {
 	case byteorder.BigEndian:
 		v = uint16(b.slice[b.offset+1]) | uint16(b.slice[b.offset])<<8
 	default:
-		return 0, ErrInvalidByteOrder
+		return 0, b.decodeError(b.offset, 2, ErrInvalidByteOrder)
 	}
 
 	b.offset += 2
@@ -75,7 +75,7 @@ func (b *Buffer) ReadUint16(e byteorder.Endian) (v uint16, err error) {
 
 // Writes an unsigned short
 func (b *Buffer) WriteUint16(v uint16, e byteorder.Endian) error {
-	if b.len-b.offset < 2 {
+	if !b.writable(2) {
 		return ErrEndOfFile
 	}
 
@@ -97,7 +97,7 @@ func (b *Buffer) WriteUint16(v uint16, e byteorder.Endian) error {
 // Reads a signed short and returns it
 func (b *Buffer) ReadInt16(e byteorder.Endian) (v int16, err error) {
 	if b.len-b.offset < 2 {
-		return 0, ErrEndOfFile
+		return 0, b.decodeError(b.offset, 2, ErrEndOfFile)
 	}
 
 	switch e {
@@ -106,7 +106,7 @@ func (b *Buffer) ReadInt16(e byteorder.Endian) (v int16, err error) {
 	case byteorder.BigEndian:
 		v = int16(b.slice[b.offset+1]) | int16(b.slice[b.offset])<<8
 	default:
-		return 0, ErrInvalidByteOrder
+		return 0, b.decodeError(b.offset, 2, ErrInvalidByteOrder)
 	}
 
 	b.offset += 2
@@ -115,7 +115,7 @@ func (b *Buffer) ReadInt16(e byteorder.Endian) (v int16, err error) {
 
 // Writes a signed short
 func (b *Buffer) WriteInt16(v int16, e byteorder.Endian) error {
-	if b.len-b.offset < 2 {
+	if !b.writable(2) {
 		return ErrEndOfFile
 	}
 
@@ -137,7 +137,7 @@ func (b *Buffer) WriteInt16(v int16, e byteorder.Endian) error {
 // Reads an unsigned 24-bit integer and returns it.
 func (b *Buffer) ReadUint24(e byteorder.Endian) (v uint32, err error) {
 	if b.len-b.offset < 3 {
-		return 0, ErrEndOfFile
+		return 0, b.decodeError(b.offset, 3, ErrEndOfFile)
 	}
 
 	switch e {
@@ -146,7 +146,7 @@ func (b *Buffer) ReadUint24(e byteorder.Endian) (v uint32, err error) {
 	case byteorder.BigEndian:
 		v = uint32(b.slice[b.offset+2]) | uint32(b.slice[b.offset+1])<<8 | uint32(b.slice[b.offset])<<16
 	default:
-		return 0, ErrInvalidByteOrder
+		return 0, b.decodeError(b.offset, 3, ErrInvalidByteOrder)
 	}
 
 	b.offset += 3
@@ -155,7 +155,7 @@ func (b *Buffer) ReadUint24(e byteorder.Endian) (v uint32, err error) {
 
 // Writes an unsigned 24-bit integer
 func (b *Buffer) WriteUint24(v uint32, e byteorder.Endian) error {
-	if b.len-b.offset < 3 {
+	if !b.writable(3) {
 		return ErrEndOfFile
 	}
 
@@ -179,7 +179,7 @@ func (b *Buffer) WriteUint24(v uint32, e byteorder.Endian) error {
 // Reads an unsigned 32-bit integer and returns it.
 func (b *Buffer) ReadUint32(e byteorder.Endian) (v uint32, err error) {
 	if b.len-b.offset < 4 {
-		return 0, ErrEndOfFile
+		return 0, b.decodeError(b.offset, 4, ErrEndOfFile)
 	}
 
 	switch e {
@@ -190,7 +190,7 @@ func (b *Buffer) ReadUint32(e byteorder.Endian) (v uint32, err error) {
 		v = uint32(b.slice[b.offset+3]) | uint32(b.slice[b.offset+2])<<8 |
 			uint32(b.slice[b.offset+1])<<16 | uint32(b.slice[b.offset])<<24
 	default:
-		return 0, ErrInvalidByteOrder
+		return 0, b.decodeError(b.offset, 4, ErrInvalidByteOrder)
 	}
 
 	b.offset += 4
@@ -199,7 +199,7 @@ func (b *Buffer) ReadUint32(e byteorder.Endian) (v uint32, err error) {
 
 // Writes an unsigned 32-bit integer.
 func (b *Buffer) WriteUint32(v uint32, e byteorder.Endian) error {
-	if b.len-b.offset < 4 {
+	if !b.writable(4) {
 		return ErrEndOfFile
 	}
 
@@ -225,7 +225,7 @@ func (b *Buffer) WriteUint32(v uint32, e byteorder.Endian) error {
 // Reads a signed 32-bit integer and returns it
 func (b *Buffer) ReadInt32(e byteorder.Endian) (v int32, err error) {
 	if b.len-b.offset < 4 {
-		return 0, ErrEndOfFile
+		return 0, b.decodeError(b.offset, 4, ErrEndOfFile)
 	}
 
 	switch e {
@@ -236,7 +236,7 @@ func (b *Buffer) ReadInt32(e byteorder.Endian) (v int32, err error) {
 		v = int32(b.slice[b.offset+3]) | int32(b.slice[b.offset+2])<<8 |
 			int32(b.slice[b.offset+1])<<16 | int32(b.slice[b.offset])<<24
 	default:
-		return 0, ErrInvalidByteOrder
+		return 0, b.decodeError(b.offset, 4, ErrInvalidByteOrder)
 	}
 
 	b.offset += 4
@@ -245,7 +245,7 @@ func (b *Buffer) ReadInt32(e byteorder.Endian) (v int32, err error) {
 
 // Writes a signed 32-bit integer
 func (b *Buffer) WriteInt32(v int32, e byteorder.Endian) error {
-	if b.len-b.offset < 4 {
+	if !b.writable(4) {
 		return ErrEndOfFile
 	}
 
@@ -271,7 +271,7 @@ func (b *Buffer) WriteInt32(v int32, e byteorder.Endian) error {
 // Reads an unsigned 64-bit integer and returns it
 func (b *Buffer) ReadUint64(e byteorder.Endian) (v uint64, err error) {
 	if b.len-b.offset < 8 {
-		return 0, ErrEndOfFile
+		return 0, b.decodeError(b.offset, 8, ErrEndOfFile)
 	}
 
 	switch e {
@@ -286,7 +286,7 @@ func (b *Buffer) ReadUint64(e byteorder.Endian) (v uint64, err error) {
 			uint64(b.slice[b.offset+3])<<32 | uint64(b.slice[b.offset+2])<<40 |
 			uint64(b.slice[b.offset+1])<<48 | uint64(b.slice[b.offset])<<56
 	default:
-		return 0, ErrInvalidByteOrder
+		return 0, b.decodeError(b.offset, 8, ErrInvalidByteOrder)
 	}
 
 	b.offset += 8
@@ -295,7 +295,7 @@ func (b *Buffer) ReadUint64(e byteorder.Endian) (v uint64, err error) {
 
 // Writes an unsigned 64-bit integer
 func (b *Buffer) WriteUint64(v uint64, e byteorder.Endian) error {
-	if b.len-b.offset < 8 {
+	if !b.writable(8) {
 		return ErrEndOfFile
 	}
 
@@ -329,7 +329,7 @@ func (b *Buffer) WriteUint64(v uint64, e byteorder.Endian) error {
 // Reads a signed 64-bit integer and returns it
 func (b *Buffer) ReadInt64(e byteorder.Endian) (v int64, err error) {
 	if b.len-b.offset < 8 {
-		return 0, ErrEndOfFile
+		return 0, b.decodeError(b.offset, 8, ErrEndOfFile)
 	}
 
 	switch e {
@@ -344,7 +344,7 @@ func (b *Buffer) ReadInt64(e byteorder.Endian) (v int64, err error) {
 			int64(b.slice[b.offset+3])<<32 | int64(b.slice[b.offset+2])<<40 |
 			int64(b.slice[b.offset+1])<<48 | int64(b.slice[b.offset])<<56
 	default:
-		return 0, ErrInvalidByteOrder
+		return 0, b.decodeError(b.offset, 8, ErrInvalidByteOrder)
 	}
 
 	b.offset += 8
@@ -353,7 +353,7 @@ func (b *Buffer) ReadInt64(e byteorder.Endian) (v int64, err error) {
 
 // Writes a signed 64-bit integer
 func (b *Buffer) WriteInt64(v int64, e byteorder.Endian) error {
-	if b.len-b.offset < 8 {
+	if !b.writable(8) {
 		return ErrEndOfFile
 	}
 
@@ -387,7 +387,7 @@ func (b *Buffer) WriteInt64(v int64, e byteorder.Endian) error {
 // Reads a 32-bit floating point decimal number and returns it
 func (b *Buffer) ReadFloat32(e byteorder.Endian) (v float32, err error) {
 	if b.len-b.offset < 4 {
-		return 0, ErrEndOfFile
+		return 0, b.decodeError(b.offset, 4, ErrEndOfFile)
 	}
 
 	switch e {
@@ -400,7 +400,7 @@ func (b *Buffer) ReadFloat32(e byteorder.Endian) (v float32, err error) {
 			uint32(b.slice[b.offset+1])<<16 | uint32(b.slice[b.offset])<<24
 		v = math.Float32frombits(bits)
 	default:
-		return 0, ErrInvalidByteOrder
+		return 0, b.decodeError(b.offset, 4, ErrInvalidByteOrder)
 	}
 
 	b.offset += 4
@@ -409,7 +409,7 @@ func (b *Buffer) ReadFloat32(e byteorder.Endian) (v float32, err error) {
 
 // Writes a 32-bit floating point decimal number
 func (b *Buffer) WriteFloat32(v float32, e byteorder.Endian) error {
-	if b.len-b.offset < 4 {
+	if !b.writable(4) {
 		return ErrEndOfFile
 	}
 
@@ -437,7 +437,7 @@ func (b *Buffer) WriteFloat32(v float32, e byteorder.Endian) error {
 // Reads a 64-bit floating point decimal number and returns it
 func (b *Buffer) ReadFloat64(e byteorder.Endian) (v float64, err error) {
 	if b.len-b.offset < 8 {
-		return 0, ErrEndOfFile
+		return 0, b.decodeError(b.offset, 8, ErrEndOfFile)
 	}
 
 	switch e {
@@ -454,7 +454,7 @@ func (b *Buffer) ReadFloat64(e byteorder.Endian) (v float64, err error) {
 			uint64(b.slice[b.offset+1])<<48 | uint64(b.slice[b.offset])<<56
 		v = math.Float64frombits(bits)
 	default:
-		return 0, ErrInvalidByteOrder
+		return 0, b.decodeError(b.offset, 8, ErrInvalidByteOrder)
 	}
 
 	b.offset += 8
@@ -463,7 +463,7 @@ func (b *Buffer) ReadFloat64(e byteorder.Endian) (v float64, err error) {
 
 // Writes a 64-bit floating point decimal number
 func (b *Buffer) WriteFloat64(v float64, e byteorder.Endian) error {
-	if b.len-b.offset < 8 {
+	if !b.writable(8) {
 		return ErrEndOfFile
 	}
 
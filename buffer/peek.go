@@ -0,0 +1,138 @@
+package buffer
+
+import "github.com/gamevidea/binary/byteorder"
+
+// Reads an unsigned byte without moving the cursor and returns it
+func (b *Buffer) PeekUint8() (uint8, error) {
+	offset := b.offset
+	v, err := b.ReadUint8()
+	b.offset = offset
+
+	return v, err
+}
+
+// Reads a signed byte without moving the cursor and returns it
+func (b *Buffer) PeekInt8() (int8, error) {
+	offset := b.offset
+	v, err := b.ReadInt8()
+	b.offset = offset
+
+	return v, err
+}
+
+// Reads an unsigned short without moving the cursor and returns it
+func (b *Buffer) PeekUint16(e byteorder.Endian) (uint16, error) {
+	offset := b.offset
+	v, err := b.ReadUint16(e)
+	b.offset = offset
+
+	return v, err
+}
+
+// Reads a signed short without moving the cursor and returns it
+func (b *Buffer) PeekInt16(e byteorder.Endian) (int16, error) {
+	offset := b.offset
+	v, err := b.ReadInt16(e)
+	b.offset = offset
+
+	return v, err
+}
+
+// Reads an unsigned 24-bit integer without moving the cursor and returns it
+func (b *Buffer) PeekUint24(e byteorder.Endian) (uint32, error) {
+	offset := b.offset
+	v, err := b.ReadUint24(e)
+	b.offset = offset
+
+	return v, err
+}
+
+// Reads an unsigned 32-bit integer without moving the cursor and returns it
+func (b *Buffer) PeekUint32(e byteorder.Endian) (uint32, error) {
+	offset := b.offset
+	v, err := b.ReadUint32(e)
+	b.offset = offset
+
+	return v, err
+}
+
+// Reads a signed 32-bit integer without moving the cursor and returns it
+func (b *Buffer) PeekInt32(e byteorder.Endian) (int32, error) {
+	offset := b.offset
+	v, err := b.ReadInt32(e)
+	b.offset = offset
+
+	return v, err
+}
+
+// Reads an unsigned 64-bit integer without moving the cursor and returns it
+func (b *Buffer) PeekUint64(e byteorder.Endian) (uint64, error) {
+	offset := b.offset
+	v, err := b.ReadUint64(e)
+	b.offset = offset
+
+	return v, err
+}
+
+// Reads a signed 64-bit integer without moving the cursor and returns it
+func (b *Buffer) PeekInt64(e byteorder.Endian) (int64, error) {
+	offset := b.offset
+	v, err := b.ReadInt64(e)
+	b.offset = offset
+
+	return v, err
+}
+
+// Reads a 32-bit floating point decimal number without moving the cursor and returns it
+func (b *Buffer) PeekFloat32(e byteorder.Endian) (float32, error) {
+	offset := b.offset
+	v, err := b.ReadFloat32(e)
+	b.offset = offset
+
+	return v, err
+}
+
+// Reads a 64-bit floating point decimal number without moving the cursor and returns it
+func (b *Buffer) PeekFloat64(e byteorder.Endian) (float64, error) {
+	offset := b.offset
+	v, err := b.ReadFloat64(e)
+	b.offset = offset
+
+	return v, err
+}
+
+// Reads an unsigned 32-bit LEB128 varint without moving the cursor and returns it
+func (b *Buffer) PeekVarUint32() (uint32, error) {
+	offset := b.offset
+	v, err := b.ReadVarUint32()
+	b.offset = offset
+
+	return v, err
+}
+
+// Reads a zigzag encoded signed 32-bit varint without moving the cursor and returns it
+func (b *Buffer) PeekVarInt32() (int32, error) {
+	offset := b.offset
+	v, err := b.ReadVarInt32()
+	b.offset = offset
+
+	return v, err
+}
+
+// Reads an unsigned 64-bit LEB128 varint without moving the cursor and returns it
+func (b *Buffer) PeekVarUint64() (uint64, error) {
+	offset := b.offset
+	v, err := b.ReadVarUint64()
+	b.offset = offset
+
+	return v, err
+}
+
+// Reads a zigzag encoded signed 64-bit varint without moving the cursor and returns it
+func (b *Buffer) PeekVarInt64() (int64, error) {
+	offset := b.offset
+	v, err := b.ReadVarInt64()
+	b.offset = offset
+
+	return v, err
+}
@@ -0,0 +1,74 @@
+package buffer
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/gamevidea/binary/byteorder"
+)
+
+// peekers calls every Peek variant and returns its value widened to 64 bits
+var peekers = map[string]func(b *Buffer) (uint64, error){
+	"PeekUint8":     func(b *Buffer) (uint64, error) { v, err := b.PeekUint8(); return uint64(v), err },
+	"PeekInt8":      func(b *Buffer) (uint64, error) { v, err := b.PeekInt8(); return uint64(v), err },
+	"PeekUint16":    func(b *Buffer) (uint64, error) { v, err := b.PeekUint16(byteorder.BigEndian); return uint64(v), err },
+	"PeekInt16":     func(b *Buffer) (uint64, error) { v, err := b.PeekInt16(byteorder.BigEndian); return uint64(v), err },
+	"PeekUint24":    func(b *Buffer) (uint64, error) { v, err := b.PeekUint24(byteorder.BigEndian); return uint64(v), err },
+	"PeekUint32":    func(b *Buffer) (uint64, error) { v, err := b.PeekUint32(byteorder.BigEndian); return uint64(v), err },
+	"PeekInt32":     func(b *Buffer) (uint64, error) { v, err := b.PeekInt32(byteorder.BigEndian); return uint64(v), err },
+	"PeekUint64":    func(b *Buffer) (uint64, error) { v, err := b.PeekUint64(byteorder.BigEndian); return v, err },
+	"PeekInt64":     func(b *Buffer) (uint64, error) { v, err := b.PeekInt64(byteorder.BigEndian); return uint64(v), err },
+	"PeekFloat32":   func(b *Buffer) (uint64, error) { v, err := b.PeekFloat32(byteorder.BigEndian); return uint64(v), err },
+	"PeekFloat64":   func(b *Buffer) (uint64, error) { v, err := b.PeekFloat64(byteorder.BigEndian); return uint64(v), err },
+	"PeekVarUint32": func(b *Buffer) (uint64, error) { v, err := b.PeekVarUint32(); return uint64(v), err },
+	"PeekVarInt32":  func(b *Buffer) (uint64, error) { v, err := b.PeekVarInt32(); return uint64(v), err },
+	"PeekVarUint64": func(b *Buffer) (uint64, error) { v, err := b.PeekVarUint64(); return v, err },
+	"PeekVarInt64":  func(b *Buffer) (uint64, error) { v, err := b.PeekVarInt64(); return uint64(v), err },
+}
+
+func TestPeekLeavesOffset(t *testing.T) {
+	data := []byte{0xff, 0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08, 0x09}
+
+	for name, peek := range peekers {
+		b := From(data)
+		b.SetOffset(1)
+
+		first, err := peek(b)
+		if err != nil {
+			t.Fatalf("%s() error = %v", name, err)
+		}
+
+		if b.Offset() != 1 {
+			t.Fatalf("%s() moved the cursor to %d, want 1", name, b.Offset())
+		}
+
+		// Peeking twice returns the same value.
+		if second, err := peek(b); err != nil || second != first {
+			t.Fatalf("%s() twice = %d and %d, %v, want the same value", name, first, second, err)
+		}
+	}
+}
+
+func TestPeekShortBuffer(t *testing.T) {
+	for name, peek := range peekers {
+		// The buffer is empty past the cursor, or holds a single byte that announces more varint bytes, which is
+		// too short for every variant wider than a byte.
+		for _, offset := range []int{1, 2} {
+			if offset == 1 && strings.HasSuffix(name, "8") {
+				continue
+			}
+
+			b := From([]byte{0x00, 0x80})
+			b.SetOffset(offset)
+
+			if _, err := peek(b); !errors.Is(err, ErrEndOfFile) {
+				t.Fatalf("%s() of a short buffer error = %v, want ErrEndOfFile", name, err)
+			}
+
+			if b.Offset() != offset {
+				t.Fatalf("%s() of a short buffer moved the cursor to %d, want %d", name, b.Offset(), offset)
+			}
+		}
+	}
+}
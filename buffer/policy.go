@@ -0,0 +1,51 @@
+package buffer
+
+// Tolerance controls how a decoding operation handles a malformed value
+type Tolerance uint8
+
+const (
+	// TolerateDefault keeps the operation's documented behaviour
+	TolerateDefault Tolerance = iota
+	// TolerateStrict rejects malformed values with an error
+	TolerateStrict
+	// TolerateLenient accepts malformed values, interpreting them as closely as possible
+	TolerateLenient
+)
+
+// Policy controls the tolerance of the decoding operations that peers are known to get wrong. The zero value
+// keeps the default behaviour of every operation.
+type Policy struct {
+	// Bool is the tolerance of ReadBool for bytes other than 0 and 1. It is strict by default, failing with
+	// ErrInvalidBool, while lenient decodes any non-zero byte as true.
+	Bool Tolerance
+	// AddrVersion is the tolerance of ReadAddr and ReadAddrPort for unknown ip versions. It is lenient by
+	// default, skipping the version byte, which resets the value read by ReadAddrPort to zero while ReadAddr
+	// leaves its address as it was. Strict fails with ErrInvalidAddrVersion instead.
+	AddrVersion Tolerance
+}
+
+var (
+	// StrictPolicy rejects every malformed value, such as for anticheats that treat them as tampering
+	StrictPolicy = Policy{Bool: TolerateStrict, AddrVersion: TolerateStrict}
+	// LenientPolicy accepts every malformed value, such as for proxies that forward whatever peers send
+	LenientPolicy = Policy{Bool: TolerateLenient, AddrVersion: TolerateLenient}
+)
+
+// Returns the decoding policy of the buffer
+func (b *Buffer) Policy() Policy {
+	return b.policy
+}
+
+// Sets the decoding policy of the buffer
+func (b *Buffer) SetPolicy(p Policy) {
+	b.policy = p
+}
+
+// Reports whether the provided tolerance is lenient, resolving TolerateDefault to the provided default
+func lenient(t Tolerance, def Tolerance) bool {
+	if t == TolerateDefault {
+		t = def
+	}
+
+	return t == TolerateLenient
+}
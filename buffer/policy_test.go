@@ -0,0 +1,96 @@
+package buffer
+
+import (
+	"errors"
+	"net"
+	"net/netip"
+	"testing"
+)
+
+func TestPolicyBool(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy Policy
+		want   bool
+		err    error
+	}{
+		{name: "default", policy: Policy{}, err: ErrInvalidBool},
+		{name: "strict", policy: StrictPolicy, err: ErrInvalidBool},
+		{name: "lenient", policy: LenientPolicy, want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, v := range []byte{0x02, 0x7f, 0xff} {
+				b := From([]byte{0x00, 0x01, v})
+				b.SetPolicy(tt.policy)
+
+				// Zero and one decode the same way under every policy.
+				if got, err := b.ReadBool(); got || err != nil {
+					t.Fatalf("ReadBool() of 0x00 = %v, %v, want false", got, err)
+				}
+
+				if got, err := b.ReadBool(); !got || err != nil {
+					t.Fatalf("ReadBool() of 0x01 = %v, %v, want true", got, err)
+				}
+
+				got, err := b.ReadBool()
+				if got != tt.want || !errors.Is(err, tt.err) {
+					t.Fatalf("ReadBool() of %#x = %v, %v, want %v, %v", v, got, err, tt.want, tt.err)
+				}
+
+				var decodeErr *DecodeError
+				if tt.err != nil && (!errors.As(err, &decodeErr) || decodeErr.Offset != 2) {
+					t.Fatalf("ReadBool() of %#x error = %v, want a *DecodeError at offset 2", v, err)
+				}
+			}
+		})
+	}
+}
+
+func TestPolicyAddrVersion(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy Policy
+		err    error
+	}{
+		{name: "default", policy: Policy{}},
+		{name: "strict", policy: StrictPolicy, err: ErrInvalidAddrVersion},
+		{name: "lenient", policy: LenientPolicy},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, version := range []byte{0x00, 0x05, 0xff} {
+				b := From([]byte{version, 0x01, 0x02})
+				b.SetPolicy(tt.policy)
+
+				got := AddrPort{AddrPort: netip.MustParseAddrPort("10.0.0.1:19132")}
+				err := b.ReadAddrPort(&got)
+				if !errors.Is(err, tt.err) {
+					t.Fatalf("ReadAddrPort() of version %d error = %v, want %v", version, err, tt.err)
+				}
+
+				if tt.err != nil {
+					var decodeErr *DecodeError
+					if !errors.As(err, &decodeErr) || decodeErr.Offset != 0 {
+						t.Fatalf("ReadAddrPort() of version %d error = %v, want a *DecodeError at offset 0", version, err)
+					}
+
+					continue
+				}
+
+				// Only the version byte is skipped and the value read is reset.
+				if got != (AddrPort{}) || b.Offset() != 1 {
+					t.Fatalf("ReadAddrPort() of version %d = %v at offset %d, want the zero value at offset 1", version, got, b.Offset())
+				}
+
+				addr := net.UDPAddr{IP: net.IPv4(10, 0, 0, 1), Port: 19132}
+				b.SetOffset(0)
+				if err := b.ReadAddr(&addr); err != nil || addr.Port != 19132 || b.Offset() != 1 {
+					t.Fatalf("ReadAddr() of version %d = %v, %v at offset %d, want the address left as it was", version, &addr, err, b.Offset())
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,106 @@
+package buffer
+
+import (
+	"math/bits"
+	"sync"
+)
+
+const (
+	// minPoolShift is the power of two of the smallest size class of a pool
+	minPoolShift = 6
+	// maxPoolShift is the power of two of the largest size class of a pool. Larger buffers are not pooled.
+	maxPoolShift = 24
+)
+
+// PoisonByte is the byte the internal slices of released buffers are filled with when poisoning is enabled
+const PoisonByte = 0xdd
+
+// Pool recycles fixed buffers in power-of-two size classes, from 64 bytes up to 16 MiB, so that buffers
+// allocated for every datagram or packet do not put pressure on the garbage collector. The zero value is
+// ready to use and a Pool is safe for concurrent use.
+type Pool struct {
+	// Poison reports whether released buffers are filled with PoisonByte and emptied, so that reads and
+	// writes through a buffer used after it was released fail or produce recognisable garbage. It is meant
+	// for debugging as it costs a pass over every released buffer.
+	Poison bool
+
+	classes [maxPoolShift - minPoolShift + 1]sync.Pool
+}
+
+// Returns the size class of the provided size, or -1 if it is negative or too large to be pooled
+func poolClass(size int) int {
+	if size < 0 {
+		return -1
+	}
+
+	if size <= 1<<minPoolShift {
+		return 0
+	}
+
+	shift := bits.Len(uint(size - 1))
+	if shift > maxPoolShift {
+		return -1
+	}
+
+	return shift - minPoolShift
+}
+
+// Returns a fixed buffer whose length is the provided size, with its cursor at the start and the default
+// decoding policy and AF_INET6 value. Its capacity is the size rounded up to the next power of two, which
+// Reset restores its length to. The buffer's contents are left over from its previous use. A negative size
+// is treated as zero.
+func (p *Pool) Get(size int) *Buffer {
+	size = max(size, 0)
+
+	class := poolClass(size)
+	if class < 0 {
+		b := New(size)
+		b.pool = p
+
+		return b
+	}
+
+	b, _ := p.classes[class].Get().(*Buffer)
+	if b == nil {
+		b = New(1 << (class + minPoolShift))
+		b.pool = p
+	}
+
+	b.released = false
+	b.len = size
+
+	return b
+}
+
+// Resets the provided buffer and returns it to the pool so that it can be reused by Get. The buffer must no
+// longer be used afterwards, and the marks taken from it can no longer be rewound to. Buffers that were not
+// allocated by the pool are left to the garbage collector, while returning a buffer that was already returned
+// panics. A buffer returned again after Get handed it out anew can not be told apart from its new owner's,
+// so such a double return goes undetected and corrupts the new owner's use of it.
+func (p *Pool) Put(b *Buffer) {
+	if b.pool != p {
+		return
+	}
+
+	if b.released {
+		panic("buffer: buffer returned to the pool twice")
+	}
+
+	b.Reset()
+	b.family = 0
+	b.policy = Policy{}
+	b.released = true
+	b.epoch++
+
+	if p.Poison {
+		for i := range b.slice {
+			b.slice[i] = PoisonByte
+		}
+
+		b.len = 0
+	}
+
+	if class := poolClass(b.cap); class >= 0 {
+		p.classes[class].Put(b)
+	}
+}
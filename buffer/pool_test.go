@@ -0,0 +1,143 @@
+package buffer
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestPoolGetNegativeSize(t *testing.T) {
+	var p Pool
+
+	b := p.Get(-1)
+	if b.Length() != 0 || b.Offset() != 0 {
+		t.Fatalf("Get(-1) returned a buffer of length %d, want 0", b.Length())
+	}
+
+	p.Put(b)
+}
+
+func TestPoolRewindAfterPut(t *testing.T) {
+	p := Pool{Poison: true}
+
+	b := p.Get(100)
+	m := b.Mark()
+	p.Put(b)
+
+	if err := b.Rewind(m); !errors.Is(err, ErrInvalidOffset) {
+		t.Fatalf("Rewind() of a released buffer error = %v, want ErrInvalidOffset", err)
+	}
+
+	if b.Length() != 0 {
+		t.Fatalf("Rewind() of a released buffer restored its length to %d", b.Length())
+	}
+
+	// A mark taken before the buffer was released stays invalid once Get hands it out again, which is
+	// simulated as the pool may return another buffer.
+	b.released = false
+	if b.Rewind(m) == nil {
+		t.Fatal("Rewind() to a mark taken before the buffer was released succeeded")
+	}
+
+	if err := b.Rewind(b.Mark()); err != nil {
+		t.Fatalf("Rewind() to a fresh mark error = %v", err)
+	}
+}
+
+func TestPoolClass(t *testing.T) {
+	tests := []struct {
+		size, class int
+	}{
+		{-1, -1},
+		{0, 0},
+		{1, 0},
+		{64, 0},
+		{65, 1},
+		{128, 1},
+		{129, 2},
+		{1500, 5},
+		{1 << maxPoolShift, maxPoolShift - minPoolShift},
+		{1<<maxPoolShift + 1, -1},
+	}
+
+	for _, tt := range tests {
+		if got := poolClass(tt.size); got != tt.class {
+			t.Errorf("poolClass(%d) = %d, want %d", tt.size, got, tt.class)
+		}
+	}
+}
+
+func TestPoolGet(t *testing.T) {
+	var p Pool
+
+	for _, tt := range []struct {
+		size, cap int
+	}{
+		{0, 64},
+		{64, 64},
+		{1500, 2048},
+		{1<<maxPoolShift + 1, 1<<maxPoolShift + 1},
+	} {
+		b := p.Get(tt.size)
+		if b.Length() != tt.size || b.Capacity() != tt.cap || b.Offset() != 0 || b.Growable() {
+			t.Fatalf("Get(%d) returned length %d and capacity %d, want %d and %d", tt.size, b.Length(), b.Capacity(), tt.size, tt.cap)
+		}
+
+		b.SetPolicy(StrictPolicy)
+		b.SetInet6Family(Inet6Linux)
+		b.Shift(tt.size)
+		p.Put(b)
+
+		if b.Offset() != 0 || b.Policy() != (Policy{}) || b.Inet6Family() != Inet6Windows {
+			t.Fatalf("Put() did not reset the buffer of size %d", tt.size)
+		}
+	}
+}
+
+func TestPoolPoison(t *testing.T) {
+	p := Pool{Poison: true}
+
+	b := p.Get(100)
+	b.Write([]byte("secret"))
+	p.Put(b)
+
+	for i, v := range b.Slice() {
+		if v != PoisonByte {
+			t.Fatalf("byte %d of a released buffer = %#x, want %#x", i, v, PoisonByte)
+		}
+	}
+
+	if _, err := b.ReadUint8(); !errors.Is(err, ErrEndOfFile) {
+		t.Fatalf("ReadUint8() of a released buffer error = %v, want ErrEndOfFile", err)
+	}
+
+	if err := b.WriteUint8(1); !errors.Is(err, ErrEndOfFile) {
+		t.Fatalf("WriteUint8() to a released buffer error = %v, want ErrEndOfFile", err)
+	}
+}
+
+func TestPoolDoublePut(t *testing.T) {
+	var p Pool
+	b := p.Get(10)
+	p.Put(b)
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("Put() of a released buffer did not panic")
+		}
+	}()
+
+	p.Put(b)
+}
+
+func TestPoolPutForeign(t *testing.T) {
+	var p, other Pool
+
+	for _, b := range []*Buffer{New(64), other.Get(64)} {
+		b.SetOffset(10)
+		p.Put(b)
+
+		if b.released || b.Offset() != 10 {
+			t.Fatal("Put() of a buffer the pool did not allocate released it")
+		}
+	}
+}
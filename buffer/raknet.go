@@ -2,7 +2,10 @@ package buffer
 
 import (
 	"bytes"
+	"fmt"
 	"net"
+	"net/netip"
+	"strconv"
 
 	"github.com/gamevidea/binary/byteorder"
 )
@@ -20,52 +23,53 @@ const (
 	ipv6 ipVersion = net.IPv6len
 )
 
-// Reads a UDP Socket Address from the buffer and returns it
-func (b *Buffer) ReadAddr(v *net.UDPAddr) error {
-	ver, err := b.ReadUint8()
-	if err != nil {
-		return err
-	}
-
-	switch ver {
-	case ipv4:
-		ipBytes := make([]byte, 4)
-		if err := b.Read(ipBytes); err != nil {
-			return err
-		}
+// Inet6Family is the value of AF_INET6 written in the family field of ipv6 addresses. It differs between
+// platforms and is written as is by raknet, so clients send different values.
+type Inet6Family uint16
 
-		port, err := b.ReadUint16(byteorder.BigEndian)
-		if err != nil {
-			return err
-		}
+const (
+	// Inet6Windows is AF_INET6 on windows, which is the default
+	Inet6Windows Inet6Family = 23
+	// Inet6Linux is AF_INET6 on linux and android
+	Inet6Linux Inet6Family = 10
+	// Inet6BSD is AF_INET6 on freebsd
+	Inet6BSD Inet6Family = 28
+)
 
-		v.IP = net.IPv4((-ipBytes[0]-1)&0xff, (-ipBytes[1]-1)&0xff, (-ipBytes[2]-1)&0xff, (-ipBytes[3]-1)&0xff)
-		v.Port = int(port)
-	case ipv6:
-		if err := b.Shift(2); err != nil {
-			return err
-		}
+const (
+	// ipv4AddrSize is the size of an encoded ipv4 address: version, complemented address and port
+	ipv4AddrSize = 1 + net.IPv4len + 2
+	// ipv6AddrSize is the size of an encoded ipv6 address: version and sockaddr_in6 of family, port, flow
+	// info, address and scope id
+	ipv6AddrSize = 1 + 2 + 2 + 4 + net.IPv6len + 4
+)
 
-		port, err := b.ReadUint16(byteorder.LittleEndian)
-		if err != nil {
-			return err
-		}
+// Returns the AF_INET6 value written in ipv6 addresses that do not carry their own
+func (b *Buffer) Inet6Family() Inet6Family {
+	if b.family == 0 {
+		return Inet6Windows
+	}
 
-		if err := b.Shift(4); err != nil {
-			return err
-		}
+	return b.family
+}
 
-		v.IP = make([]byte, 16)
-		if err := b.Read(v.IP); err != nil {
-			return err
-		}
+// Sets the AF_INET6 value written in ipv6 addresses that do not carry their own. Zero restores the default,
+// Inet6Windows.
+func (b *Buffer) SetInet6Family(f Inet6Family) {
+	b.family = f
+}
 
-		if err := b.Shift(4); err != nil {
-			return err
-		}
+// Reads a UDP Socket Address from the buffer and returns it. The ip is always a newly allocated slice, so
+// that it does not alias the one of a previously decoded address. The scope id of ipv6 addresses is stored as a
+// numeric zone, while the family and flow info are dropped as net.UDPAddr can not hold them. Use
+// ReadAddrPort to preserve them. Unknown ip versions are handled as described by ReadAddrPort.
+func (b *Buffer) ReadAddr(v *net.UDPAddr) error {
+	var a AddrPort
+	if err := b.ReadAddrPort(&a); err != nil {
+		return err
+	}
 
-		v.Port = int(port)
-	default:
+	if !a.IsValid() {
 		/*
 		 * HACK: This may be completely fine to return no error upon encountering an invalid IP version because upon
 		 * using wireshark to investigate how system addresses are sent by the client, their version is encoded incorrectly
@@ -74,59 +78,163 @@ func (b *Buffer) ReadAddr(v *net.UDPAddr) error {
 		return nil
 	}
 
+	ip := a.Addr().As16()
+	v.IP = make(net.IP, net.IPv6len)
+	copy(v.IP, ip[:])
+	v.Port = int(a.Port())
+	v.Zone = ""
+
+	if a.Addr().Is6() && a.ScopeID != 0 {
+		v.Zone = strconv.FormatUint(uint64(a.ScopeID), 10)
+	}
+
 	return nil
 }
 
-// Writes a UDP Socket Address to the buffer.
-func (b *Buffer) WriteAddr(v *net.UDPAddr) error {
+// Returns the number of bytes the provided UDP Socket Address takes when written to the buffer
+func AddrSize(v *net.UDPAddr) int {
 	if v.IP.To4() != nil {
-		if err := b.WriteUint8(ipv4); err != nil {
-			return err
-		}
+		return ipv4AddrSize
+	}
 
-		ipBytes := v.IP.To4()
-		if err := b.WriteUint8(^ipBytes[0]); err != nil {
-			return err
-		}
-		if err := b.WriteUint8(^ipBytes[1]); err != nil {
-			return err
-		}
-		if err := b.WriteUint8(^ipBytes[2]); err != nil {
-			return err
+	return ipv6AddrSize
+}
+
+// Writes a UDP Socket Address to the buffer. The zone of ipv6 addresses is written as their scope id, either
+// as a number or as the index of the interface it names, and the family is the buffer's Inet6Family.
+func (b *Buffer) WriteAddr(v *net.UDPAddr) error {
+	ip, _ := netip.AddrFromSlice(v.IP)
+	a := AddrPort{AddrPort: netip.AddrPortFrom(ip, uint16(v.Port))}
+
+	if v.Zone != "" && v.IP.To4() == nil {
+		a.ScopeID = scopeID(v.Zone)
+	}
+
+	return b.WriteAddrPort(&a)
+}
+
+// Returns the scope id of an ipv6 zone, which is either a number or the name of an interface
+func scopeID(zone string) uint32 {
+	if id, err := strconv.ParseUint(zone, 10, 32); err == nil {
+		return uint32(id)
+	}
+
+	if ifi, err := net.InterfaceByName(zone); err == nil {
+		return uint32(ifi.Index)
+	}
+
+	return 0
+}
+
+// AddrPort is a UDP Socket Address decoded without allocating. Family, FlowInfo and ScopeID are the fields of
+// sockaddr_in6 that netip.AddrPort can not hold, and are only present on ipv6 addresses.
+type AddrPort struct {
+	netip.AddrPort
+	// Family is the AF_INET6 value of the address. If zero, the buffer's Inet6Family is written.
+	Family   Inet6Family
+	FlowInfo uint32
+	ScopeID  uint32
+}
+
+// Reads a UDP Socket Address from the buffer into the provided value without allocating and returns an error
+// if the operation was unsuccessful. Unknown ip versions are skipped without an error, leaving the value
+// zero, unless the buffer's policy is strict for address versions.
+func (b *Buffer) ReadAddrPort(v *AddrPort) error {
+	ver, err := b.ReadUint8()
+	if err != nil {
+		return err
+	}
+
+	switch ver {
+	case ipv4:
+		if b.len-b.offset < ipv4AddrSize-1 {
+			return b.decodeError(b.offset, ipv4AddrSize-1, ErrEndOfFile)
 		}
-		if err := b.WriteUint8(^ipBytes[3]); err != nil {
-			return err
+
+		s := b.slice[b.offset : b.offset+ipv4AddrSize-1]
+		b.offset += ipv4AddrSize - 1
+
+		ip := netip.AddrFrom4([4]byte{^s[0], ^s[1], ^s[2], ^s[3]})
+		*v = AddrPort{AddrPort: netip.AddrPortFrom(ip, uint16(s[4])<<8|uint16(s[5]))}
+	case ipv6:
+		if b.len-b.offset < ipv6AddrSize-1 {
+			return b.decodeError(b.offset, ipv6AddrSize-1, ErrEndOfFile)
 		}
 
-		if err := b.WriteUint16(uint16(v.Port), byteorder.BigEndian); err != nil {
-			return err
+		s := b.slice[b.offset : b.offset+ipv6AddrSize-1]
+		b.offset += ipv6AddrSize - 1
+
+		ip := netip.AddrFrom16([16]byte(s[8:24]))
+		*v = AddrPort{
+			AddrPort: netip.AddrPortFrom(ip, uint16(s[2])<<8|uint16(s[3])),
+			Family:   Inet6Family(uint16(s[0]) | uint16(s[1])<<8),
+			FlowInfo: uint32(s[4])<<24 | uint32(s[5])<<16 | uint32(s[6])<<8 | uint32(s[7]),
+			ScopeID:  uint32(s[24]) | uint32(s[25])<<8 | uint32(s[26])<<16 | uint32(s[27])<<24,
 		}
-	} else {
-		if err := b.WriteUint8(ipv6); err != nil {
-			return err
+	default:
+		if !lenient(b.policy.AddrVersion, TolerateLenient) {
+			return b.decodeError(b.offset-1, 1, ErrInvalidAddrVersion)
 		}
 
-		if err := b.WriteInt16(23, byteorder.LittleEndian); err != nil {
-			return err
-		}
+		*v = AddrPort{}
+	}
 
-		if err := b.WriteUint16(uint16(v.Port), byteorder.BigEndian); err != nil {
-			return err
-		}
+	return nil
+}
 
-		if err := b.WriteInt32(0, byteorder.BigEndian); err != nil {
-			return err
-		}
+// Returns the number of bytes the provided UDP Socket Address takes when written to the buffer
+func AddrPortSize(v *AddrPort) int {
+	if v.Addr().Unmap().Is4() {
+		return ipv4AddrSize
+	}
 
-		if err := b.Write(v.IP.To16()); err != nil {
-			return err
-		}
+	return ipv6AddrSize
+}
 
-		if err := b.WriteInt32(0, byteorder.BigEndian); err != nil {
-			return err
+// Writes a UDP Socket Address to the buffer without allocating and returns an error if the operation was
+// unsuccessful. IPv4-mapped ipv6 addresses are written as ipv4 addresses. The family and scope id are written
+// in little endian, the host byte order of sockaddr_in6 on every platform minecraft runs on, and the flow
+// info in network byte order.
+func (b *Buffer) WriteAddrPort(v *AddrPort) error {
+	ip := v.Addr().Unmap()
+	port := v.Port()
+
+	if ip.Is4() {
+		if !b.writable(ipv4AddrSize) {
+			return ErrEndOfFile
 		}
+
+		s := b.slice[b.offset : b.offset+ipv4AddrSize]
+		b.offset += ipv4AddrSize
+
+		a := ip.As4()
+		s[0] = ipv4
+		s[1], s[2], s[3], s[4] = ^a[0], ^a[1], ^a[2], ^a[3]
+		s[5], s[6] = byte(port>>8), byte(port)
+
+		return nil
 	}
 
+	if !b.writable(ipv6AddrSize) {
+		return ErrEndOfFile
+	}
+
+	s := b.slice[b.offset : b.offset+ipv6AddrSize]
+	b.offset += ipv6AddrSize
+
+	family := v.Family
+	if family == 0 {
+		family = b.Inet6Family()
+	}
+
+	a := ip.As16()
+	s[0] = ipv6
+	s[1], s[2] = byte(family), byte(family>>8)
+	s[3], s[4] = byte(port>>8), byte(port)
+	s[5], s[6], s[7], s[8] = byte(v.FlowInfo>>24), byte(v.FlowInfo>>16), byte(v.FlowInfo>>8), byte(v.FlowInfo)
+	copy(s[9:25], a[:])
+	s[25], s[26], s[27], s[28] = byte(v.ScopeID), byte(v.ScopeID>>8), byte(v.ScopeID>>16), byte(v.ScopeID>>24)
+
 	return nil
 }
 
@@ -137,14 +245,14 @@ var magic = [16]byte{0x00, 0xff, 0xff, 0x00, 0xfe, 0xfe, 0xfe, 0xfe, 0xfd, 0xfd,
 // unsuccessful.
 func (b *Buffer) ReadMagic() error {
 	if b.len-b.offset < 16 {
-		return ErrEndOfFile
+		return b.decodeError(b.offset, 16, ErrEndOfFile)
 	}
 
 	slice := b.slice[b.offset : b.offset+16]
 	b.offset += 16
 
 	if !bytes.Equal(slice, magic[:]) {
-		return ErrInvalidMagic
+		return b.decodeError(b.offset-16, 16, ErrInvalidMagic)
 	}
 
 	return nil
@@ -153,7 +261,7 @@ func (b *Buffer) ReadMagic() error {
 // Writes the unconnected message sequence to the underlying buffer and returns an error if the operation
 // was unsuccessful.
 func (b *Buffer) WriteMagic() error {
-	if b.len-b.offset < 16 {
+	if !b.writable(16) {
 		return ErrEndOfFile
 	}
 
@@ -164,44 +272,43 @@ func (b *Buffer) WriteMagic() error {
 	return nil
 }
 
-// Regular RakNet uses 10 by default. MCPE uses 20. Configure this as appropriate
-const SYSTEM_ADDRESSES_COUNT = 20
-
-// HACK: This is the number of bytes left at which we should stop reading system addresses. This is useful
-// for scenarios where a RakNet server sends less number of system addresses than the configured one. Such as a
-// MCPE servers sends 20 (modern raknet servers) while traditional servers may send 10.
-//
-// NOTE: Make this dynamic in future in case microjang decides to alter packet fields in either of packets having,
-// system addresses.
-const readDeadline = 16
-
-// Reads system addresses from the buffer into the provided slice and returns an error if the
-// operation was unsuccessful.
-func (b *Buffer) ReadSystemAddresses() error {
-	var addr = net.UDPAddr{}
-
-	for i := 0; i < SYSTEM_ADDRESSES_COUNT; i++ {
-		if err := b.ReadAddr(&addr); err != nil {
-			return err
-		}
+// Number of system addresses sent in the handshake. Regular RakNet uses 10 by default, while MCPE uses 20.
+const (
+	SystemAddressesRakNet = 10
+	SystemAddressesMCPE   = 20
+)
 
-		if b.len-b.offset == readDeadline {
-			break
+// placeholderAddr is written in place of system addresses that are not provided
+var placeholderAddr = net.UDPAddr{IP: net.IPv4bcast, Port: 19132}
+
+// Reads count system addresses from the buffer, appending them to the provided slice, and returns the
+// resulting slice. The count is not on the wire, so it must be the one the peer writes, which is usually
+// SystemAddressesRakNet or SystemAddressesMCPE. Addresses of an unknown ip version are appended as zero values.
+func (b *Buffer) ReadSystemAddresses(v []net.UDPAddr, count int) ([]net.UDPAddr, error) {
+	for i := 0; i < count; i++ {
+		v = append(v, net.UDPAddr{})
+		if err := b.ReadAddr(&v[len(v)-1]); err != nil {
+			return v, WithField(err, fmt.Sprintf("[%d]", i))
 		}
 	}
 
-	return nil
+	return v, nil
 }
 
-// Writes system addresses from the provided slice in the underlying buffer and returns an error if the
-// operation was unsuccessful.
-func (b *Buffer) WriteSystemAddresses() error {
-	addr, err := net.ResolveUDPAddr("udp", "255.255.255.255:19132")
-	if err != nil {
-		return err
+// Writes count system addresses from the provided slice to the underlying buffer and returns an error if
+// the operation was unsuccessful. If the slice holds fewer addresses, the rest is filled with
+// 255.255.255.255:19132, while ErrLengthMismatch is returned if it holds more.
+func (b *Buffer) WriteSystemAddresses(v []net.UDPAddr, count int) error {
+	if len(v) > count {
+		return fmt.Errorf("%w: %d system addresses, count %d", ErrLengthMismatch, len(v), count)
 	}
 
-	for i := 0; i < SYSTEM_ADDRESSES_COUNT; i++ {
+	for i := 0; i < count; i++ {
+		addr := &placeholderAddr
+		if i < len(v) {
+			addr = &v[i]
+		}
+
 		if err := b.WriteAddr(addr); err != nil {
 			return err
 		}
@@ -211,8 +318,11 @@ func (b *Buffer) WriteSystemAddresses() error {
 }
 
 // Reads the raknet pong data from the buffer into the provided slice and returns an error
-// if the operation failed
+// if the operation failed. Data of a negative length or longer than the slice is rejected with
+// ErrInvalidLength.
 func (b *Buffer) ReadPongData(buf []byte) error {
+	max := len(buf)
+
 	l, err := b.ReadInt16(byteorder.BigEndian)
 	if err != nil {
 		return err
@@ -220,8 +330,12 @@ func (b *Buffer) ReadPongData(buf []byte) error {
 
 	len := int(l)
 
+	if len < 0 || len > max {
+		return b.decodeError(b.offset-2, 2, ErrInvalidLength)
+	}
+
 	if b.len-b.offset < len {
-		return ErrEndOfFile
+		return b.decodeError(b.offset, len, ErrEndOfFile)
 	}
 
 	copy(buf[:len], b.slice[b.offset:b.offset+len])
@@ -239,7 +353,7 @@ func (b *Buffer) WritePongData(buf []byte) error {
 		return err
 	}
 
-	if b.len-b.offset < len {
+	if !b.writable(len) {
 		return ErrEndOfFile
 	}
 
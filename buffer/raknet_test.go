@@ -0,0 +1,158 @@
+package buffer
+
+import (
+	"errors"
+	"net"
+	"net/netip"
+	"testing"
+)
+
+func TestAddrSize(t *testing.T) {
+	for _, addr := range []*net.UDPAddr{
+		{IP: net.IPv4(192, 168, 0, 1), Port: 19132},
+		{IP: net.ParseIP("2001:db8::1"), Port: 19133},
+	} {
+		b := NewGrowable(0)
+		if err := b.WriteAddr(addr); err != nil {
+			t.Fatalf("WriteAddr(%v) error = %v", addr, err)
+		}
+
+		if got := AddrSize(addr); got != b.Offset() {
+			t.Errorf("AddrSize(%v) = %d, WriteAddr wrote %d bytes", addr, got, b.Offset())
+		}
+	}
+}
+
+func TestAddrIPv6RoundTrip(t *testing.T) {
+	addr := &net.UDPAddr{IP: net.ParseIP("2001:db8::1"), Port: 0x4abc}
+
+	b := NewGrowable(0)
+	if err := b.WriteAddr(addr); err != nil {
+		t.Fatalf("WriteAddr() error = %v", err)
+	}
+
+	// The port follows the version and family and is in network byte order, like the one of ipv4 addresses.
+	if s := b.Bytes(); s[3] != 0x4a || s[4] != 0xbc {
+		t.Fatalf("WriteAddr() wrote port bytes %x, want 4abc", s[3:5])
+	}
+
+	b.Resize(b.Offset())
+	b.SetOffset(0)
+
+	var got net.UDPAddr
+	if err := b.ReadAddr(&got); err != nil {
+		t.Fatalf("ReadAddr() error = %v", err)
+	}
+
+	if !got.IP.Equal(addr.IP) || got.Port != addr.Port {
+		t.Fatalf("ReadAddr() = %v, want %v", &got, addr)
+	}
+}
+
+func TestReadAddrDoesNotAlias(t *testing.T) {
+	b := NewGrowable(0)
+	b.WriteAddr(&net.UDPAddr{IP: net.IPv4(10, 0, 0, 1), Port: 1})
+	b.WriteAddr(&net.UDPAddr{IP: net.IPv4(10, 0, 0, 2), Port: 2})
+	b.Resize(b.Offset())
+	b.SetOffset(0)
+
+	var addr net.UDPAddr
+	if err := b.ReadAddr(&addr); err != nil {
+		t.Fatalf("ReadAddr() error = %v", err)
+	}
+	saved := addr
+
+	if err := b.ReadAddr(&addr); err != nil {
+		t.Fatalf("ReadAddr() error = %v", err)
+	}
+
+	if !saved.IP.Equal(net.IPv4(10, 0, 0, 1)) {
+		t.Fatalf("the first address read was overwritten with %v by the second", saved.IP)
+	}
+}
+
+func TestAddrPortFields(t *testing.T) {
+	for _, family := range []Inet6Family{Inet6Windows, Inet6Linux, Inet6BSD} {
+		want := AddrPort{
+			AddrPort: netip.MustParseAddrPort("[fe80::1]:19132"),
+			Family:   family,
+			FlowInfo: 0x01020304,
+			ScopeID:  0x0a0b0c0d,
+		}
+
+		b := NewGrowable(0)
+		if err := b.WriteAddrPort(&want); err != nil {
+			t.Fatalf("WriteAddrPort() error = %v", err)
+		}
+		b.Resize(b.Offset())
+		b.SetOffset(0)
+
+		var got AddrPort
+		if err := b.ReadAddrPort(&got); err != nil {
+			t.Fatalf("ReadAddrPort() error = %v", err)
+		}
+
+		if got != want {
+			t.Errorf("ReadAddrPort() = %+v, want %+v", got, want)
+		}
+	}
+}
+
+func TestPongData(t *testing.T) {
+	b := NewGrowable(0)
+	if err := b.WritePongData([]byte("MCPE;motd")); err != nil {
+		t.Fatalf("WritePongData() error = %v", err)
+	}
+
+	buf := make([]byte, 32)
+	if err := From(b.Bytes()).ReadPongData(buf); err != nil || string(buf[:9]) != "MCPE;motd" {
+		t.Fatalf("ReadPongData() = %q, %v", buf[:9], err)
+	}
+
+	tests := []struct {
+		name string
+		data []byte
+		err  error
+	}{
+		{"negative length", []byte{0xff, 0xff, 'a'}, ErrInvalidLength},
+		{"longer than the slice", append([]byte{0x00, 0x21}, make([]byte, 33)...), ErrInvalidLength},
+		{"truncated", []byte{0x00, 0x03, 'a'}, ErrEndOfFile},
+	}
+
+	for _, tt := range tests {
+		var decodeErr *DecodeError
+		if err := From(tt.data).ReadPongData(buf); !errors.As(err, &decodeErr) || !errors.Is(err, tt.err) {
+			t.Errorf("ReadPongData() of %s error = %v, want a *DecodeError wrapping %v", tt.name, err, tt.err)
+		}
+	}
+}
+
+func TestAddrPortAllocs(t *testing.T) {
+	for _, v := range []AddrPort{
+		{AddrPort: netip.MustParseAddrPort("192.168.1.2:19132")},
+		{AddrPort: netip.MustParseAddrPort("[fe80::1]:19132"), Family: 23, FlowInfo: 1, ScopeID: 2},
+	} {
+		b := New(AddrPortSize(&v))
+
+		var got AddrPort
+		allocs := testing.AllocsPerRun(100, func() {
+			b.SetOffset(0)
+			if err := b.WriteAddrPort(&v); err != nil {
+				t.Fatal(err)
+			}
+
+			b.SetOffset(0)
+			if err := b.ReadAddrPort(&got); err != nil {
+				t.Fatal(err)
+			}
+		})
+
+		if allocs != 0 {
+			t.Fatalf("WriteAddrPort() and ReadAddrPort() of %v allocated %v times, want 0", v, allocs)
+		}
+
+		if got != v {
+			t.Fatalf("ReadAddrPort() = %v, want %v", got, v)
+		}
+	}
+}
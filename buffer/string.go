@@ -0,0 +1,240 @@
+package buffer
+
+import (
+	"math"
+	"unicode/utf8"
+
+	"github.com/gamevidea/binary/byteorder"
+)
+
+// Prefix is the datatype used to encode the length that precedes a string or a byte slice on the wire.
+type Prefix uint8
+
+const (
+	// PrefixVarUint32 encodes the length as an unsigned 32-bit varint, used by the bedrock protocol
+	PrefixVarUint32 Prefix = iota
+	// PrefixUint16LE encodes the length as a little-endian unsigned short, used by little-endian NBT
+	PrefixUint16LE
+	// PrefixUint16BE encodes the length as a big-endian unsigned short, used by raknet and big-endian NBT
+	PrefixUint16BE
+	// PrefixInt32LE encodes the length as a little-endian signed 32-bit integer
+	PrefixInt32LE
+)
+
+// Layout describes how a length prefixed string or byte slice is laid out on the wire and how strictly
+// it is validated.
+type Layout struct {
+	// Prefix is the datatype used to encode the length of the payload
+	Prefix Prefix
+	// MaxLength is the maximum number of bytes accepted for the payload. Zero only restricts the length to
+	// the range of the prefix.
+	MaxLength int
+	// ValidateUTF8 rejects strings that are not valid UTF-8 when set. It has no effect on byte slices.
+	ValidateUTF8 bool
+}
+
+var (
+	// BedrockLayout is the layout used by strings and byte slices in bedrock game packets
+	BedrockLayout = Layout{Prefix: PrefixVarUint32}
+	// RakNetLayout is the layout used by strings in raknet messages
+	RakNetLayout = Layout{Prefix: PrefixUint16BE}
+)
+
+// Returns the maximum payload length that can be represented by the layout's prefix
+func (l Layout) limit() int {
+	var n int
+	switch l.Prefix {
+	case PrefixUint16LE, PrefixUint16BE:
+		n = math.MaxUint16
+	case PrefixVarUint32, PrefixInt32LE:
+		n = math.MaxInt32
+	default:
+		return -1
+	}
+
+	if l.MaxLength > 0 && l.MaxLength < n {
+		n = l.MaxLength
+	}
+
+	return n
+}
+
+// Returns the number of bytes a payload of the provided length takes when encoded with the layout,
+// including its length prefix.
+func (l Layout) Size(n int) int {
+	return l.PrefixSize(n) + n
+}
+
+// Returns the number of bytes the length prefix of the layout takes for the provided length
+func (l Layout) PrefixSize(n int) int {
+	switch l.Prefix {
+	case PrefixVarUint32:
+		return VarUint32Size(uint32(n))
+	case PrefixUint16LE, PrefixUint16BE:
+		return 2
+	case PrefixInt32LE:
+		return 4
+	}
+
+	return 0
+}
+
+// Reads the length prefix of the layout and validates it against the layout's limits. As every element
+// takes at least one byte, lengths larger than the number of bytes left in the buffer are rejected so that
+// callers can safely allocate the returned number of elements. The cursor is left untouched if the
+// operation failed.
+func (b *Buffer) ReadLength(l Layout) (int, error) {
+	start := b.offset
+
+	limit := l.limit()
+	if limit < 0 {
+		return 0, b.decodeError(start, 0, ErrInvalidPrefix)
+	}
+
+	var n int
+	switch l.Prefix {
+	case PrefixVarUint32:
+		v, err := b.ReadVarUint32()
+		if err != nil {
+			return 0, err
+		}
+		n = int(v)
+	case PrefixUint16LE:
+		v, err := b.ReadUint16(byteorder.LittleEndian)
+		if err != nil {
+			return 0, err
+		}
+		n = int(v)
+	case PrefixUint16BE:
+		v, err := b.ReadUint16(byteorder.BigEndian)
+		if err != nil {
+			return 0, err
+		}
+		n = int(v)
+	case PrefixInt32LE:
+		v, err := b.ReadInt32(byteorder.LittleEndian)
+		if err != nil {
+			return 0, err
+		}
+		n = int(v)
+	}
+
+	if n < 0 || n > limit {
+		err := b.decodeError(start, b.offset-start, ErrInvalidLength)
+		b.offset = start
+		return 0, err
+	}
+
+	if n > b.len-b.offset {
+		err := b.decodeError(start, b.offset-start+n, ErrEndOfFile)
+		b.offset = start
+		return 0, err
+	}
+
+	return n, nil
+}
+
+// Writes the length prefix of the layout after validating the length against the layout's limits, which
+// rejects negative lengths as well
+func (b *Buffer) WriteLength(n int, l Layout) error {
+	limit := l.limit()
+	if limit < 0 {
+		return ErrInvalidPrefix
+	}
+
+	if n < 0 || n > limit {
+		return ErrInvalidLength
+	}
+
+	switch l.Prefix {
+	case PrefixVarUint32:
+		return b.WriteVarUint32(uint32(n))
+	case PrefixUint16LE:
+		return b.WriteUint16(uint16(n), byteorder.LittleEndian)
+	case PrefixUint16BE:
+		return b.WriteUint16(uint16(n), byteorder.BigEndian)
+	case PrefixInt32LE:
+		return b.WriteInt32(int32(n), byteorder.LittleEndian)
+	}
+
+	return nil
+}
+
+// Reads a length prefixed byte slice laid out as described by the provided layout and returns a shared
+// reference to the buffer's internal slice. The cursor is left untouched if the operation failed.
+func (b *Buffer) ReadByteSlice(l Layout) ([]byte, error) {
+	n, err := b.ReadLength(l)
+	if err != nil {
+		return nil, err
+	}
+
+	slice := b.slice[b.offset : b.offset+n]
+	b.offset += n
+
+	return slice, nil
+}
+
+// Writes the provided byte slice preceded by its length laid out as described by the provided layout.
+// The cursor is left untouched if the operation failed.
+func (b *Buffer) WriteByteSlice(v []byte, l Layout) error {
+	start := b.offset
+
+	if err := b.WriteLength(len(v), l); err != nil {
+		b.offset = start
+		return err
+	}
+
+	if !b.writable(len(v)) {
+		b.offset = start
+		return ErrEndOfFile
+	}
+
+	copy(b.slice[b.offset:b.offset+len(v)], v)
+	b.offset += len(v)
+
+	return nil
+}
+
+// Reads a length prefixed string laid out as described by the provided layout and returns it. The cursor
+// is left untouched if the operation failed.
+func (b *Buffer) ReadString(l Layout) (string, error) {
+	start := b.offset
+
+	slice, err := b.ReadByteSlice(l)
+	if err != nil {
+		return "", err
+	}
+
+	if l.ValidateUTF8 && !utf8.Valid(slice) {
+		err := b.decodeError(b.offset-len(slice), len(slice), ErrInvalidUTF8)
+		b.offset = start
+		return "", err
+	}
+
+	return string(slice), nil
+}
+
+// Writes the provided string preceded by its length laid out as described by the provided layout. The
+// cursor is left untouched if the operation failed.
+func (b *Buffer) WriteString(v string, l Layout) error {
+	if l.ValidateUTF8 && !utf8.ValidString(v) {
+		return ErrInvalidUTF8
+	}
+
+	start := b.offset
+
+	if err := b.WriteLength(len(v), l); err != nil {
+		b.offset = start
+		return err
+	}
+
+	if !b.writable(len(v)) {
+		b.offset = start
+		return ErrEndOfFile
+	}
+
+	copy(b.slice[b.offset:b.offset+len(v)], v)
+	b.offset += len(v)
+
+	return nil
+}
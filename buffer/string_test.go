@@ -0,0 +1,168 @@
+package buffer
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestLayoutPrefixSize(t *testing.T) {
+	tests := []struct {
+		layout Layout
+		n      int
+		want   int
+	}{
+		{Layout{Prefix: PrefixVarUint32}, 0, 1},
+		{Layout{Prefix: PrefixVarUint32}, 128, 2},
+		{Layout{Prefix: PrefixUint16LE}, 300, 2},
+		{Layout{Prefix: PrefixUint16BE}, 300, 2},
+		{Layout{Prefix: PrefixInt32LE}, 300, 4},
+	}
+
+	for _, tt := range tests {
+		if got := tt.layout.PrefixSize(tt.n); got != tt.want {
+			t.Errorf("%v.PrefixSize(%d) = %d, want %d", tt.layout.Prefix, tt.n, got, tt.want)
+		}
+
+		if got := tt.layout.Size(tt.n); got != tt.want+tt.n {
+			t.Errorf("%v.Size(%d) = %d, want %d", tt.layout.Prefix, tt.n, got, tt.want+tt.n)
+		}
+
+		b := NewGrowable(0)
+		if err := b.WriteLength(tt.n, tt.layout); err != nil || b.Offset() != tt.want {
+			t.Errorf("%v.WriteLength(%d) wrote %d bytes, %v, want %d", tt.layout.Prefix, tt.n, b.Offset(), err, tt.want)
+		}
+	}
+}
+
+func TestReadLength(t *testing.T) {
+	b := NewGrowable(0)
+	if err := b.WriteLength(3, RakNetLayout); err != nil {
+		t.Fatal(err)
+	}
+	b.Write([]byte{1, 2, 3})
+
+	r := From(b.Bytes())
+	if n, err := r.ReadLength(RakNetLayout); err != nil || n != 3 || r.Offset() != 2 {
+		t.Fatalf("ReadLength() = %d, %v at offset %d, want 3 at offset 2", n, err, r.Offset())
+	}
+
+	// Lengths exceeding the bytes left are rejected without moving the cursor.
+	r = From(b.Bytes()[:4])
+	if _, err := r.ReadLength(RakNetLayout); !errors.Is(err, ErrEndOfFile) || r.Offset() != 0 {
+		t.Fatalf("ReadLength() error = %v at offset %d, want ErrEndOfFile at offset 0", err, r.Offset())
+	}
+
+	r = From(b.Bytes())
+	if _, err := r.ReadLength(Layout{Prefix: PrefixUint16BE, MaxLength: 2}); !errors.Is(err, ErrInvalidLength) || r.Offset() != 0 {
+		t.Fatalf("ReadLength() error = %v at offset %d, want ErrInvalidLength at offset 0", err, r.Offset())
+	}
+
+	if err := NewGrowable(0).WriteLength(3, Layout{MaxLength: 2}); !errors.Is(err, ErrInvalidLength) {
+		t.Fatalf("WriteLength() error = %v, want ErrInvalidLength", err)
+	}
+}
+
+func TestWriteLengthNegative(t *testing.T) {
+	for _, prefix := range []Prefix{PrefixVarUint32, PrefixUint16LE, PrefixUint16BE, PrefixInt32LE} {
+		b := NewGrowable(0)
+		if err := b.WriteLength(-1, Layout{Prefix: prefix}); !errors.Is(err, ErrInvalidLength) || b.Offset() != 0 {
+			t.Errorf("%v.WriteLength(-1) error = %v after writing %d bytes, want ErrInvalidLength", prefix, err, b.Offset())
+		}
+	}
+}
+
+func TestStringRoundTrip(t *testing.T) {
+	layouts := []Layout{
+		{Prefix: PrefixVarUint32},
+		{Prefix: PrefixUint16LE},
+		{Prefix: PrefixUint16BE},
+		{Prefix: PrefixInt32LE},
+	}
+
+	for _, l := range layouts {
+		for _, v := range []string{"", "steve", strings.Repeat("§", 200)} {
+			b := NewGrowable(0)
+			if err := b.WriteString(v, l); err != nil {
+				t.Fatalf("%v.WriteString(%q) error = %v", l.Prefix, v, err)
+			}
+
+			if b.Offset() != l.Size(len(v)) {
+				t.Fatalf("%v.WriteString(%q) wrote %d bytes, want %d", l.Prefix, v, b.Offset(), l.Size(len(v)))
+			}
+
+			r := From(b.Bytes())
+			if got, err := r.ReadString(l); err != nil || got != v || r.Remaining() != 0 {
+				t.Fatalf("%v.ReadString() = %q, %v, want %q", l.Prefix, got, err, v)
+			}
+		}
+	}
+}
+
+func TestByteSliceRoundTrip(t *testing.T) {
+	v := []byte{0xff, 0x00, 0xfe}
+
+	b := NewGrowable(0)
+	if err := b.WriteByteSlice(v, BedrockLayout); err != nil {
+		t.Fatalf("WriteByteSlice() error = %v", err)
+	}
+
+	if !bytes.Equal(b.Bytes(), []byte{3, 0xff, 0x00, 0xfe}) {
+		t.Fatalf("WriteByteSlice() wrote %x", b.Bytes())
+	}
+
+	r := From(b.Bytes())
+	got, err := r.ReadByteSlice(BedrockLayout)
+	if err != nil || !bytes.Equal(got, v) {
+		t.Fatalf("ReadByteSlice() = %x, %v, want %x", got, err, v)
+	}
+
+	// The slice returned is a shared reference to the buffer's internal slice.
+	if &got[0] != &r.Slice()[1] {
+		t.Fatal("ReadByteSlice() returned a copy of the buffer's internal slice")
+	}
+}
+
+func TestStringMaxLength(t *testing.T) {
+	l := Layout{Prefix: PrefixVarUint32, MaxLength: 4}
+
+	b := NewGrowable(0)
+	if err := b.WriteString("steve", l); !errors.Is(err, ErrInvalidLength) || b.Offset() != 0 {
+		t.Fatalf("WriteString() error = %v after writing %d bytes, want ErrInvalidLength", err, b.Offset())
+	}
+
+	r := From([]byte{5, 's', 't', 'e', 'v', 'e'})
+	if _, err := r.ReadString(l); !errors.Is(err, ErrInvalidLength) || r.Offset() != 0 {
+		t.Fatalf("ReadString() error = %v at offset %d, want ErrInvalidLength at offset 0", err, r.Offset())
+	}
+
+	r = From([]byte{5, 's', 't', 'e'})
+	if _, err := r.ReadByteSlice(BedrockLayout); !errors.Is(err, ErrEndOfFile) || r.Offset() != 0 {
+		t.Fatalf("ReadByteSlice() of a truncated payload error = %v at offset %d, want ErrEndOfFile at offset 0", err, r.Offset())
+	}
+
+	if err := New(3).WriteString("steve", BedrockLayout); !errors.Is(err, ErrEndOfFile) {
+		t.Fatalf("WriteString() into a full buffer error = %v, want ErrEndOfFile", err)
+	}
+}
+
+func TestStringValidateUTF8(t *testing.T) {
+	invalid := string([]byte{0xff, 0xfe})
+	l := Layout{Prefix: PrefixVarUint32, ValidateUTF8: true}
+
+	if err := NewGrowable(0).WriteString(invalid, l); !errors.Is(err, ErrInvalidUTF8) {
+		t.Fatalf("WriteString() of invalid UTF-8 error = %v, want ErrInvalidUTF8", err)
+	}
+
+	r := From([]byte{2, 0xff, 0xfe})
+	if _, err := r.ReadString(l); !errors.Is(err, ErrInvalidUTF8) || r.Offset() != 0 {
+		t.Fatalf("ReadString() of invalid UTF-8 error = %v at offset %d, want ErrInvalidUTF8 at offset 0", err, r.Offset())
+	}
+
+	// Validation is opt-in, so the bytes are returned as is without it.
+	r.SetOffset(0)
+	if got, err := r.ReadString(BedrockLayout); err != nil || got != invalid {
+		t.Fatalf("ReadString() without validation = %q, %v", got, err)
+	}
+}
@@ -0,0 +1,178 @@
+package buffer
+
+const (
+	// maxVarUint32Len is the maximum number of bytes a 32-bit LEB128 varint can be encoded in
+	maxVarUint32Len = 5
+	// maxVarUint64Len is the maximum number of bytes a 64-bit LEB128 varint can be encoded in
+	maxVarUint64Len = 10
+)
+
+// Returns the number of bytes the provided value takes when encoded as an unsigned 32-bit varint
+func VarUint32Size(v uint32) int {
+	n := 1
+	for v >= 0x80 {
+		v >>= 7
+		n++
+	}
+
+	return n
+}
+
+// Returns the number of bytes the provided value takes when encoded as a zigzag 32-bit varint
+func VarInt32Size(v int32) int {
+	return VarUint32Size(zigzag32(v))
+}
+
+// Returns the number of bytes the provided value takes when encoded as an unsigned 64-bit varint
+func VarUint64Size(v uint64) int {
+	n := 1
+	for v >= 0x80 {
+		v >>= 7
+		n++
+	}
+
+	return n
+}
+
+// Returns the number of bytes the provided value takes when encoded as a zigzag 64-bit varint
+func VarInt64Size(v int64) int {
+	return VarUint64Size(zigzag64(v))
+}
+
+// Reads an unsigned 32-bit LEB128 varint and returns it
+func (b *Buffer) ReadVarUint32() (v uint32, err error) {
+	for i := 0; i < maxVarUint32Len; i++ {
+		if b.len-b.offset-i < 1 {
+			return 0, b.decodeError(b.offset, i+1, ErrEndOfFile)
+		}
+
+		c := b.slice[b.offset+i]
+		v |= uint32(c&0x7f) << (7 * i)
+
+		if c&0x80 == 0 {
+			// The last byte of a 32-bit varint can only carry 4 significant bits and a trailing zero
+			// group means the value could have been encoded in fewer bytes.
+			if (i == maxVarUint32Len-1 && c > 0x0f) || (i > 0 && c == 0) {
+				return 0, b.decodeError(b.offset, i+1, ErrOverlongVarInt)
+			}
+
+			b.offset += i + 1
+			return v, nil
+		}
+	}
+
+	return 0, b.decodeError(b.offset, maxVarUint32Len, ErrOverlongVarInt)
+}
+
+// Writes an unsigned 32-bit LEB128 varint
+func (b *Buffer) WriteVarUint32(v uint32) error {
+	if !b.writable(VarUint32Size(v)) {
+		return ErrEndOfFile
+	}
+
+	for v >= 0x80 {
+		b.slice[b.offset] = byte(v) | 0x80
+		b.offset += 1
+		v >>= 7
+	}
+
+	b.slice[b.offset] = byte(v)
+	b.offset += 1
+
+	return nil
+}
+
+// Reads a zigzag encoded signed 32-bit varint and returns it
+func (b *Buffer) ReadVarInt32() (int32, error) {
+	v, err := b.ReadVarUint32()
+	if err != nil {
+		return 0, err
+	}
+
+	return unzigzag32(v), nil
+}
+
+// Writes a zigzag encoded signed 32-bit varint
+func (b *Buffer) WriteVarInt32(v int32) error {
+	return b.WriteVarUint32(zigzag32(v))
+}
+
+// Reads an unsigned 64-bit LEB128 varint and returns it
+func (b *Buffer) ReadVarUint64() (v uint64, err error) {
+	for i := 0; i < maxVarUint64Len; i++ {
+		if b.len-b.offset-i < 1 {
+			return 0, b.decodeError(b.offset, i+1, ErrEndOfFile)
+		}
+
+		c := b.slice[b.offset+i]
+		v |= uint64(c&0x7f) << (7 * i)
+
+		if c&0x80 == 0 {
+			// The last byte of a 64-bit varint can only carry a single significant bit and a trailing zero
+			// group means the value could have been encoded in fewer bytes.
+			if (i == maxVarUint64Len-1 && c > 0x01) || (i > 0 && c == 0) {
+				return 0, b.decodeError(b.offset, i+1, ErrOverlongVarInt)
+			}
+
+			b.offset += i + 1
+			return v, nil
+		}
+	}
+
+	return 0, b.decodeError(b.offset, maxVarUint64Len, ErrOverlongVarInt)
+}
+
+// Writes an unsigned 64-bit LEB128 varint
+func (b *Buffer) WriteVarUint64(v uint64) error {
+	if !b.writable(VarUint64Size(v)) {
+		return ErrEndOfFile
+	}
+
+	for v >= 0x80 {
+		b.slice[b.offset] = byte(v) | 0x80
+		b.offset += 1
+		v >>= 7
+	}
+
+	b.slice[b.offset] = byte(v)
+	b.offset += 1
+
+	return nil
+}
+
+// Reads a zigzag encoded signed 64-bit varint and returns it
+func (b *Buffer) ReadVarInt64() (int64, error) {
+	v, err := b.ReadVarUint64()
+	if err != nil {
+		return 0, err
+	}
+
+	return unzigzag64(v), nil
+}
+
+// Writes a zigzag encoded signed 64-bit varint
+func (b *Buffer) WriteVarInt64(v int64) error {
+	return b.WriteVarUint64(zigzag64(v))
+}
+
+// zigzag32 maps a signed 32-bit integer to an unsigned one so that values of small magnitude have
+// small encodings.
+func zigzag32(v int32) uint32 {
+	return uint32(v<<1) ^ uint32(v>>31)
+}
+
+// unzigzag32 reverses zigzag32.
+func unzigzag32(v uint32) int32 {
+	return int32(v>>1) ^ -int32(v&1)
+}
+
+// zigzag64 maps a signed 64-bit integer to an unsigned one so that values of small magnitude have
+// small encodings.
+func zigzag64(v int64) uint64 {
+	return uint64(v<<1) ^ uint64(v>>63)
+}
+
+// unzigzag64 reverses zigzag64.
+func unzigzag64(v uint64) int64 {
+	return int64(v>>1) ^ -int64(v&1)
+}
@@ -0,0 +1,143 @@
+package buffer
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestVarUint32RoundTrip(t *testing.T) {
+	for _, v := range []uint32{0, 1, 0x7f, 0x80, 0x3fff, 0x4000, 0x1fffff, 0x200000, 0xfffffff, 0x10000000, math.MaxUint32} {
+		b := NewGrowable(0)
+		if err := b.WriteVarUint32(v); err != nil {
+			t.Fatalf("WriteVarUint32(%d): %v", v, err)
+		}
+
+		if b.Offset() != VarUint32Size(v) {
+			t.Fatalf("WriteVarUint32(%d) wrote %d bytes, VarUint32Size returned %d", v, b.Offset(), VarUint32Size(v))
+		}
+
+		r := From(b.Bytes())
+		got, err := r.ReadVarUint32()
+		if err != nil || got != v || r.Remaining() != 0 {
+			t.Fatalf("ReadVarUint32() = %d, %v, want %d with nothing left", got, err, v)
+		}
+	}
+}
+
+func TestVarUint64RoundTrip(t *testing.T) {
+	for _, v := range []uint64{0, 1, 0x7f, 0x80, math.MaxUint32, 1 << 56, 1<<63 - 1, 1 << 63, math.MaxUint64} {
+		b := NewGrowable(0)
+		if err := b.WriteVarUint64(v); err != nil {
+			t.Fatalf("WriteVarUint64(%d): %v", v, err)
+		}
+
+		if b.Offset() != VarUint64Size(v) {
+			t.Fatalf("WriteVarUint64(%d) wrote %d bytes, VarUint64Size returned %d", v, b.Offset(), VarUint64Size(v))
+		}
+
+		r := From(b.Bytes())
+		got, err := r.ReadVarUint64()
+		if err != nil || got != v || r.Remaining() != 0 {
+			t.Fatalf("ReadVarUint64() = %d, %v, want %d with nothing left", got, err, v)
+		}
+	}
+}
+
+func TestZigZag(t *testing.T) {
+	tests32 := []struct {
+		v    int32
+		want uint32
+	}{
+		{0, 0}, {-1, 1}, {1, 2}, {-2, 3}, {math.MaxInt32, math.MaxUint32 - 1}, {math.MinInt32, math.MaxUint32},
+	}
+	for _, tt := range tests32 {
+		if got := zigzag32(tt.v); got != tt.want {
+			t.Errorf("zigzag32(%d) = %d, want %d", tt.v, got, tt.want)
+		}
+
+		if got := unzigzag32(tt.want); got != tt.v {
+			t.Errorf("unzigzag32(%d) = %d, want %d", tt.want, got, tt.v)
+		}
+	}
+
+	for _, v := range []int64{0, -1, 1, math.MaxInt64, math.MinInt64} {
+		b := NewGrowable(0)
+		if err := b.WriteVarInt64(v); err != nil {
+			t.Fatal(err)
+		}
+
+		got, err := From(b.Bytes()).ReadVarInt64()
+		if err != nil || got != v {
+			t.Fatalf("ReadVarInt64() = %d, %v, want %d", got, err, v)
+		}
+	}
+}
+
+func TestReadVarUint32Invalid(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+		err  error
+	}{
+		{"empty", nil, ErrEndOfFile},
+		{"truncated", []byte{0x80, 0x80}, ErrEndOfFile},
+		{"trailing zero group", []byte{0x80, 0x00}, ErrOverlongVarInt},
+		{"padded zero", []byte{0xff, 0x80, 0x80, 0x80, 0x00}, ErrOverlongVarInt},
+		{"overflowing last byte", []byte{0xff, 0xff, 0xff, 0xff, 0x1f}, ErrOverlongVarInt},
+		{"too long", []byte{0xff, 0xff, 0xff, 0xff, 0xff, 0x01}, ErrOverlongVarInt},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := From(tt.data)
+			if _, err := b.ReadVarUint32(); !errors.Is(err, tt.err) {
+				t.Fatalf("ReadVarUint32() error = %v, want %v", err, tt.err)
+			}
+
+			if b.Offset() != 0 {
+				t.Fatalf("ReadVarUint32() moved the cursor to %d on failure", b.Offset())
+			}
+		})
+	}
+}
+
+func TestReadVarUint64Invalid(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+		err  error
+	}{
+		{"truncated", []byte{0xff, 0xff, 0xff}, ErrEndOfFile},
+		{"trailing zero group", []byte{0x81, 0x80, 0x00}, ErrOverlongVarInt},
+		{"overflowing last byte", []byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x02}, ErrOverlongVarInt},
+		{"too long", []byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x81, 0x01}, ErrOverlongVarInt},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := From(tt.data)
+			if _, err := b.ReadVarUint64(); !errors.Is(err, tt.err) {
+				t.Fatalf("ReadVarUint64() error = %v, want %v", err, tt.err)
+			}
+
+			if b.Offset() != 0 {
+				t.Fatalf("ReadVarUint64() moved the cursor to %d on failure", b.Offset())
+			}
+		})
+	}
+}
+
+func TestReadVarUint32Boundary(t *testing.T) {
+	// The largest value fits in five bytes whose last one carries four significant bits.
+	b := From([]byte{0xff, 0xff, 0xff, 0xff, 0x0f})
+	if v, err := b.ReadVarUint32(); err != nil || v != math.MaxUint32 {
+		t.Fatalf("ReadVarUint32() = %d, %v, want %d", v, err, uint32(math.MaxUint32))
+	}
+
+	var d *DecodeError
+	_, err := From([]byte{0x80, 0x00}).ReadVarUint32()
+	if !errors.As(err, &d) || d.Offset != 0 || d.Size != 2 {
+		t.Fatalf("ReadVarUint32() error = %#v, want a DecodeError at offset 0 of size 2", err)
+	}
+}
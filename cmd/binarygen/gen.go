@@ -0,0 +1,788 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/types"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gamevidea/binary/byteorder"
+	"github.com/gamevidea/binary/internal/bintag"
+)
+
+// shapeKind is the way a value is encoded on the wire
+type shapeKind int
+
+const (
+	// shapeBasic is a boolean, number or string encoded by a single Read*/Write* call
+	shapeBasic shapeKind = iota
+	// shapeBytes is a length prefixed byte slice
+	shapeBytes
+	// shapeByteArray is a byte array written as is
+	shapeByteArray
+	// shapeSlice is a length prefixed slice of elements
+	shapeSlice
+	// shapeArray is an array of elements
+	shapeArray
+	// shapeNested is a struct that has generated methods of its own
+	shapeNested
+	// shapeAddr is a raknet UDP socket address
+	shapeAddr
+)
+
+// shape describes how a value of a specific type is encoded, mirroring the coders compiled by buffer.Marshal
+type shape struct {
+	kind shapeKind
+	// typ is the Go type expression of the value, used for conversions and allocations
+	typ  string
+	expr ast.Expr
+	tag  bintag.Tag
+	wire bintag.Kind
+	elem *shape
+}
+
+// basicType describes a predeclared Go type
+type basicType struct {
+	bits     int
+	unsigned bool
+	signed   bool
+	float    bool
+}
+
+// basicTypes are the predeclared types that can be encoded as a basic shape
+var basicTypes = map[string]basicType{
+	"bool": {}, "string": {},
+	"uint8": {bits: 8, unsigned: true}, "byte": {bits: 8, unsigned: true}, "uint16": {bits: 16, unsigned: true},
+	"uint32": {bits: 32, unsigned: true}, "uint64": {bits: 64, unsigned: true}, "uint": {bits: 64, unsigned: true},
+	"int8": {bits: 8, signed: true}, "int16": {bits: 16, signed: true}, "int32": {bits: 32, signed: true},
+	"rune": {bits: 32, signed: true}, "int64": {bits: 64, signed: true}, "int": {bits: 64, signed: true},
+	"float32": {bits: 32, float: true}, "float64": {bits: 64, float: true},
+}
+
+// generator emits the codecs of the structs of a package
+type generator struct {
+	pkg *pkg
+	w   bytes.Buffer
+
+	// queue is the list of structs to generate, structs used as fields are appended while generating
+	queue []string
+	seen  map[string]bool
+
+	usesFmt       bool
+	usesByteorder bool
+	// imports is the set of import paths of the packages referenced by the generated type expressions
+	imports map[string]bool
+}
+
+// Generates the codecs of the provided structs and the structs they depend on, returning formatted source
+func generate(p *pkg, names []string) ([]byte, error) {
+	g := &generator{pkg: p, seen: make(map[string]bool), imports: make(map[string]bool)}
+	for _, name := range names {
+		g.enqueue(name)
+	}
+
+	var body bytes.Buffer
+	for i := 0; i < len(g.queue); i++ {
+		if err := g.generateStruct(g.queue[i]); err != nil {
+			return nil, err
+		}
+
+		body.Write(g.w.Bytes())
+		g.w.Reset()
+	}
+
+	if g.usesFmt {
+		g.imports["fmt"] = true
+	}
+	if g.usesByteorder {
+		g.imports["github.com/gamevidea/binary/byteorder"] = true
+	}
+	g.imports["github.com/gamevidea/binary/buffer"] = true
+
+	// Standard library imports are grouped before the others as goimports does.
+	var std, others []string
+	for path := range g.imports {
+		if strings.Contains(strings.Split(path, "/")[0], ".") {
+			others = append(others, path)
+		} else {
+			std = append(std, path)
+		}
+	}
+	sort.Strings(std)
+	sort.Strings(others)
+
+	g.w.WriteString("// Code generated by binarygen. DO NOT EDIT.\n\n")
+	fmt.Fprintf(&g.w, "package %s\n\nimport (\n", p.name)
+	for _, path := range std {
+		fmt.Fprintf(&g.w, "\t%q\n", path)
+	}
+	g.w.WriteString("\n")
+	for _, path := range others {
+		fmt.Fprintf(&g.w, "\t%q\n", path)
+	}
+	g.w.WriteString(")\n")
+	g.w.Write(body.Bytes())
+
+	src, err := format.Source(g.w.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("formatting generated code: %w", err)
+	}
+
+	return src, nil
+}
+
+// Adds the provided struct to the generation queue unless it was added before
+func (g *generator) enqueue(name string) {
+	if !g.seen[name] {
+		g.seen[name] = true
+		g.queue = append(g.queue, name)
+	}
+}
+
+// Returns the Go expression of the type of the provided shape and records the imports it references
+func (g *generator) typeOf(s *shape) string {
+	ast.Inspect(s.expr, func(n ast.Node) bool {
+		if sel, ok := n.(*ast.SelectorExpr); ok {
+			if ident, ok := sel.X.(*ast.Ident); ok {
+				if path, ok := g.pkg.imports[ident.Name]; ok {
+					g.imports[path] = true
+				}
+			}
+
+			return false
+		}
+
+		return true
+	})
+
+	return s.typ
+}
+
+// Writes formatted code to the output
+func (g *generator) p(format string, args ...any) {
+	fmt.Fprintf(&g.w, format, args...)
+	g.w.WriteByte('\n')
+}
+
+// structField is an encoded field of a struct
+type structField struct {
+	name  string
+	shape *shape
+	// count is the name of the field holding the element count of this field or empty if it has none
+	count string
+}
+
+// Generates the codec of the provided struct
+func (g *generator) generateStruct(name string) error {
+	ts, ok := g.pkg.types[name]
+	if !ok {
+		return fmt.Errorf("type %s not found in package %s", name, g.pkg.name)
+	}
+
+	st, ok := ts.Type.(*ast.StructType)
+	if !ok {
+		return fmt.Errorf("type %s is not a struct", name)
+	}
+
+	fields, err := g.fields(name, st)
+	if err != nil {
+		return err
+	}
+
+	g.p("")
+	g.p("// Encodes %s into the buffer and returns an error if the operation failed.", name)
+	g.p("func (x *%s) MarshalBinaryTo(b *buffer.Buffer) error {", name)
+	for _, f := range fields {
+		g.encodeField(f)
+	}
+	g.p("return nil")
+	g.p("}")
+
+	g.p("")
+	g.p("// Decodes %s from the buffer and returns an error if the operation failed.", name)
+	g.p("func (x *%s) UnmarshalBinaryFrom(b *buffer.Buffer) error {", name)
+	for _, f := range fields {
+		g.decodeField(f)
+	}
+	g.p("return nil")
+	g.p("}")
+
+	g.p("")
+	g.p("// Returns the number of bytes %s takes when encoded into a buffer.", name)
+	g.p("func (x *%s) EncodedSize() int {", name)
+	g.p("n := 0")
+	for _, f := range fields {
+		if f.count != "" && f.shape.kind == shapeBytes {
+			g.p("n += len(x.%s)", f.name)
+			continue
+		}
+
+		g.size(f.shape, "x."+f.name, 0, f.count != "")
+	}
+	g.p("return n")
+	g.p("}")
+
+	return nil
+}
+
+// Resolves the encoded fields of the provided struct following the rules of buffer.Marshal
+func (g *generator) fields(name string, st *ast.StructType) ([]structField, error) {
+	var fields []structField
+	integers := make(map[string]bool)
+
+	for _, f := range st.Fields.List {
+		var tagValue string
+		if f.Tag != nil {
+			raw, err := strconv.Unquote(f.Tag.Value)
+			if err != nil {
+				return nil, fmt.Errorf("%s: invalid struct tag %s", name, f.Tag.Value)
+			}
+			tagValue = reflect.StructTag(raw).Get(bintag.Name)
+		}
+
+		if len(f.Names) == 0 {
+			return nil, fmt.Errorf("%s: embedded fields are not supported", name)
+		}
+
+		for _, ident := range f.Names {
+			if !ident.IsExported() {
+				continue
+			}
+
+			tag, err := bintag.Parse(tagValue)
+			if err != nil {
+				return nil, fmt.Errorf("%s.%s: %w", name, ident.Name, err)
+			}
+
+			if tag.Skip {
+				continue
+			}
+
+			sf := structField{name: ident.Name}
+			if tag.Len != "" {
+				if !integers[tag.Len] {
+					return nil, fmt.Errorf("%s.%s: %w: len field %q must be an integer field declared before", name, ident.Name, bintag.ErrInvalidTag, tag.Len)
+				}
+
+				sf.count = tag.Len
+				sf.shape, err = g.counted(f.Type, tag)
+			} else {
+				sf.shape, err = g.resolve(f.Type, tag)
+			}
+
+			if err != nil {
+				return nil, fmt.Errorf("%s.%s: %w", name, ident.Name, err)
+			}
+
+			if bt, ok := g.underlyingBasic(f.Type); ok && bt.bits > 0 && !bt.float {
+				integers[ident.Name] = true
+			}
+
+			fields = append(fields, sf)
+		}
+	}
+
+	return fields, nil
+}
+
+// Returns the type expression that the provided type is declared as, following local named types
+func (g *generator) underlying(expr ast.Expr) ast.Expr {
+	for i := 0; i < 16; i++ {
+		ident, ok := expr.(*ast.Ident)
+		if !ok {
+			return expr
+		}
+
+		ts, ok := g.pkg.types[ident.Name]
+		if !ok {
+			return expr
+		}
+
+		if _, ok := ts.Type.(*ast.StructType); ok {
+			return expr
+		}
+
+		expr = ts.Type
+	}
+
+	return expr
+}
+
+// Returns the predeclared type that the provided type is declared as
+func (g *generator) underlyingBasic(expr ast.Expr) (basicType, bool) {
+	ident, ok := g.underlying(expr).(*ast.Ident)
+	if !ok {
+		return basicType{}, false
+	}
+
+	bt, ok := basicTypes[ident.Name]
+	return bt, ok
+}
+
+// Reports whether the provided type is a byte
+func (g *generator) isByte(expr ast.Expr) bool {
+	ident, ok := g.underlying(expr).(*ast.Ident)
+	return ok && (ident.Name == "byte" || ident.Name == "uint8")
+}
+
+// Resolves the shape of a value of the provided type as described by the tag
+func (g *generator) resolve(expr ast.Expr, tag bintag.Tag) (*shape, error) {
+	s := &shape{typ: types.ExprString(expr), expr: expr, tag: tag}
+	under := g.underlying(expr)
+	unsupported := fmt.Errorf("unsupported type %s as %q", s.typ, tag.Kind)
+
+	// Types implementing buffer.Codec by hand are encoded by their own methods like buffer.Marshal does.
+	if ident, ok := expr.(*ast.Ident); ok && g.pkg.codec(ident.Name) && tag.Kind == bintag.KindNone {
+		if !g.pkg.methods[ident.Name]["EncodedSize"] {
+			return nil, fmt.Errorf("type %s implements buffer.Codec but has no EncodedSize method", ident.Name)
+		}
+
+		s.kind = shapeNested
+		return s, nil
+	}
+
+	switch t := under.(type) {
+	case *ast.SelectorExpr:
+		if types.ExprString(t) == "net.UDPAddr" {
+			if tag.Kind != bintag.KindNone && tag.Kind != bintag.KindAddr {
+				return nil, unsupported
+			}
+
+			s.kind = shapeAddr
+			return s, nil
+		}
+
+		if tag.Kind != bintag.KindNone {
+			return nil, unsupported
+		}
+
+		// Structs of other packages are expected to have generated codecs of their own.
+		s.kind = shapeNested
+		return s, nil
+	case *ast.ArrayType:
+		bytes := g.isByte(t.Elt) && (tag.Kind == bintag.KindNone || tag.Kind == bintag.KindBytes)
+
+		switch {
+		case t.Len == nil && bytes:
+			s.kind = shapeBytes
+			return s, nil
+		case bytes:
+			s.kind = shapeByteArray
+			return s, nil
+		case t.Len == nil:
+			s.kind = shapeSlice
+		default:
+			s.kind = shapeArray
+		}
+
+		elem, err := g.resolve(t.Elt, tag)
+		if err != nil {
+			return nil, err
+		}
+
+		s.elem = elem
+		return s, nil
+	case *ast.Ident:
+		if ts, ok := g.pkg.types[t.Name]; ok {
+			if _, ok := ts.Type.(*ast.StructType); ok {
+				if tag.Kind != bintag.KindNone {
+					return nil, unsupported
+				}
+
+				if !g.pkg.codec(t.Name) {
+					g.enqueue(t.Name)
+				}
+
+				s.kind = shapeNested
+				return s, nil
+			}
+		}
+
+		bt, ok := basicTypes[t.Name]
+		if !ok {
+			return nil, unsupported
+		}
+
+		kind := tag.Kind
+		if kind == bintag.KindNone {
+			kind = bintag.Infer(t.Name)
+		}
+
+		switch {
+		case kind == bintag.KindBool && t.Name == "bool", kind == bintag.KindString && t.Name == "string":
+		case bt.bits == 0:
+			return nil, unsupported
+		default:
+			kind = kind.Resolve(bt.bits)
+			switch {
+			case kind.Unsigned() && !bt.unsigned, kind.Signed() && !bt.signed, kind.Float() && !bt.float,
+				kind.Bits() == 0 || kind.Bits() > bt.bits:
+				return nil, unsupported
+			}
+		}
+
+		s.kind = shapeBasic
+		s.wire = kind
+		return s, nil
+	}
+
+	return nil, unsupported
+}
+
+// Resolves the shape of a slice whose element count is held by another field
+func (g *generator) counted(expr ast.Expr, tag bintag.Tag) (*shape, error) {
+	t, ok := g.underlying(expr).(*ast.ArrayType)
+	if !ok || t.Len != nil {
+		return nil, fmt.Errorf("unsupported len option on %s", types.ExprString(expr))
+	}
+
+	s := &shape{typ: types.ExprString(expr), expr: expr, tag: tag}
+	if g.isByte(t.Elt) && (tag.Kind == bintag.KindNone || tag.Kind == bintag.KindBytes) {
+		s.kind = shapeBytes
+		return s, nil
+	}
+
+	elem, err := g.resolve(t.Elt, tag)
+	if err != nil {
+		return nil, err
+	}
+
+	s.kind = shapeSlice
+	s.elem = elem
+	return s, nil
+}
+
+// Returns the Go expression of the byte order of the provided tag
+func (g *generator) endian(tag bintag.Tag) string {
+	g.usesByteorder = true
+	if tag.Endian == byteorder.BigEndian {
+		return "byteorder.BigEndian"
+	}
+
+	return "byteorder.LittleEndian"
+}
+
+// Returns the Go expression of the layout of length prefixed values described by the tag
+func layout(tag bintag.Tag) string {
+	fields := []string{}
+	switch tag.Prefix {
+	case bintag.PrefixUint16LE:
+		fields = append(fields, "Prefix: buffer.PrefixUint16LE")
+	case bintag.PrefixUint16BE:
+		fields = append(fields, "Prefix: buffer.PrefixUint16BE")
+	case bintag.PrefixInt32LE:
+		fields = append(fields, "Prefix: buffer.PrefixInt32LE")
+	default:
+		fields = append(fields, "Prefix: buffer.PrefixVarUint32")
+	}
+
+	if tag.Max > 0 {
+		fields = append(fields, fmt.Sprintf("MaxLength: %d", tag.Max))
+	}
+
+	if tag.UTF8 {
+		fields = append(fields, "ValidateUTF8: true")
+	}
+
+	return "buffer.Layout{" + strings.Join(fields, ", ") + "}"
+}
+
+// Returns the Write* call encoding a basic value and the Read* call decoding it
+func (g *generator) calls(s *shape, expr string) (write string, read string) {
+	switch s.wire {
+	case bintag.KindBool:
+		return fmt.Sprintf("b.WriteBool(bool(%s))", expr), "b.ReadBool()"
+	case bintag.KindString:
+		l := layout(s.tag)
+		return fmt.Sprintf("b.WriteString(string(%s), %s)", expr, l), fmt.Sprintf("b.ReadString(%s)", l)
+	case bintag.KindUint8:
+		return fmt.Sprintf("b.WriteUint8(uint8(%s))", expr), "b.ReadUint8()"
+	case bintag.KindInt8:
+		return fmt.Sprintf("b.WriteInt8(int8(%s))", expr), "b.ReadInt8()"
+	case bintag.KindVarUint32:
+		return fmt.Sprintf("b.WriteVarUint32(uint32(%s))", expr), "b.ReadVarUint32()"
+	case bintag.KindVarInt32:
+		return fmt.Sprintf("b.WriteVarInt32(int32(%s))", expr), "b.ReadVarInt32()"
+	case bintag.KindVarUint64:
+		return fmt.Sprintf("b.WriteVarUint64(uint64(%s))", expr), "b.ReadVarUint64()"
+	case bintag.KindVarInt64:
+		return fmt.Sprintf("b.WriteVarInt64(int64(%s))", expr), "b.ReadVarInt64()"
+	}
+
+	var method, conv string
+	switch s.wire {
+	case bintag.KindUint16:
+		method, conv = "Uint16", "uint16"
+	case bintag.KindInt16:
+		method, conv = "Int16", "int16"
+	case bintag.KindUint24:
+		method, conv = "Uint24", "uint32"
+	case bintag.KindUint32:
+		method, conv = "Uint32", "uint32"
+	case bintag.KindInt32:
+		method, conv = "Int32", "int32"
+	case bintag.KindUint64:
+		method, conv = "Uint64", "uint64"
+	case bintag.KindInt64:
+		method, conv = "Int64", "int64"
+	case bintag.KindFloat32:
+		method, conv = "Float32", "float32"
+	case bintag.KindFloat64:
+		method, conv = "Float64", "float64"
+	}
+
+	e := g.endian(s.tag)
+	return fmt.Sprintf("b.Write%s(%s(%s), %s)", method, conv, expr, e), fmt.Sprintf("b.Read%s(%s)", method, e)
+}
+
+// Returns the number of bytes a basic value of the provided shape takes or zero if it depends on the value
+func fixedSize(s *shape) int {
+	switch s.wire {
+	case bintag.KindBool, bintag.KindUint8, bintag.KindInt8:
+		return 1
+	case bintag.KindUint16, bintag.KindInt16:
+		return 2
+	case bintag.KindUint24:
+		return 3
+	case bintag.KindUint32, bintag.KindInt32, bintag.KindFloat32:
+		return 4
+	case bintag.KindUint64, bintag.KindInt64, bintag.KindFloat64:
+		return 8
+	}
+
+	return 0
+}
+
+// Emits the statements encoding the provided field
+func (g *generator) encodeField(f structField) {
+	g.usesFmt = true
+	ret := fmt.Sprintf("return fmt.Errorf(%q, err)", f.name+": %w")
+
+	if f.count != "" {
+		msg := f.name + ": %w: %d elements, count %d"
+		g.p("if len(x.%s) != int(x.%s) {", f.name, f.count)
+		g.p("return fmt.Errorf(%q, buffer.ErrLengthMismatch, len(x.%s), int(x.%s))", msg, f.name, f.count)
+		g.p("}")
+
+		if f.shape.kind == shapeBytes {
+			g.p("if _, err := b.Write(x.%s); err != nil {", f.name)
+			g.p("%s", ret)
+			g.p("}")
+			return
+		}
+
+		g.encodeElements(f.shape.elem, "x."+f.name, 0, ret)
+		return
+	}
+
+	g.encode(f.shape, "x."+f.name, 0, ret)
+}
+
+// Emits the statements encoding a value of the provided shape
+func (g *generator) encode(s *shape, expr string, depth int, ret string) {
+	switch s.kind {
+	case shapeBasic:
+		write, _ := g.calls(s, expr)
+		g.p("if err := %s; err != nil {", write)
+	case shapeBytes:
+		g.p("if err := b.WriteByteSlice(%s, %s); err != nil {", expr, layout(s.tag))
+	case shapeByteArray:
+		g.p("if _, err := b.Write(%s[:]); err != nil {", expr)
+	case shapeNested:
+		g.p("if err := %s.MarshalBinaryTo(b); err != nil {", expr)
+	case shapeAddr:
+		g.p("if err := b.WriteAddr(&%s); err != nil {", expr)
+	case shapeSlice:
+		g.p("if err := b.WriteLength(len(%s), %s); err != nil {", expr, layout(s.tag))
+		g.p("%s", ret)
+		g.p("}")
+		g.encodeElements(s.elem, expr, depth, ret)
+		return
+	case shapeArray:
+		g.encodeElements(s.elem, expr, depth, ret)
+		return
+	}
+
+	g.p("%s", ret)
+	g.p("}")
+}
+
+// Emits a loop encoding every element of a slice or array
+func (g *generator) encodeElements(elem *shape, expr string, depth int, ret string) {
+	i := fmt.Sprintf("i%d", depth)
+	g.p("for %s := range %s {", i, expr)
+	g.encode(elem, fmt.Sprintf("%s[%s]", expr, i), depth+1, ret)
+	g.p("}")
+}
+
+// fieldPath is the Go expression of the field path that decode errors are annotated with
+type fieldPath struct {
+	format string
+	args   []string
+}
+
+// Returns the Go expression of the field path
+func (p fieldPath) expr() string {
+	if len(p.args) == 0 {
+		return strconv.Quote(p.format)
+	}
+
+	return fmt.Sprintf("fmt.Sprintf(%q, %s)", p.format, strings.Join(p.args, ", "))
+}
+
+// Returns the field path of the element at the provided index variable
+func (p fieldPath) index(i string) fieldPath {
+	return fieldPath{format: p.format + "[%d]", args: append(append([]string(nil), p.args...), i)}
+}
+
+// Emits the statements decoding the provided field
+func (g *generator) decodeField(f structField) {
+	path := fieldPath{format: f.name}
+
+	if f.count != "" {
+		wrap := fmt.Sprintf("return buffer.WithField(%%s, %s)", path.expr())
+		invalid := "&buffer.DecodeError{Offset: b.Offset(), Remaining: b.Remaining(), Err: buffer.ErrInvalidLength}"
+		eof := "&buffer.DecodeError{Offset: b.Offset(), Size: n, Remaining: b.Remaining(), Err: buffer.ErrEndOfFile}"
+
+		g.p("{")
+		g.p("n := int(x.%s)", f.count)
+		if f.shape.tag.Max > 0 {
+			g.p("if n < 0 || n > %d {", f.shape.tag.Max)
+		} else {
+			g.p("if n < 0 {")
+		}
+		g.p("%s", fmt.Sprintf(wrap, invalid))
+		g.p("}")
+		g.p("if n > b.Remaining() {")
+		g.p("%s", fmt.Sprintf(wrap, eof))
+		g.p("}")
+		g.p("x.%s = make(%s, n)", f.name, g.typeOf(f.shape))
+
+		if f.shape.kind == shapeBytes {
+			g.p("if err := b.ReadFull(x.%s); err != nil {", f.name)
+			g.p("%s", fmt.Sprintf(wrap, "err"))
+			g.p("}")
+			g.p("}")
+			return
+		}
+
+		g.p("}")
+		g.decodeElements(f.shape.elem, "x."+f.name, 0, path)
+		return
+	}
+
+	g.decode(f.shape, "x."+f.name, 0, path)
+}
+
+// Emits the statements decoding a value of the provided shape
+func (g *generator) decode(s *shape, expr string, depth int, path fieldPath) {
+	if len(path.args) > 0 {
+		g.usesFmt = true
+	}
+
+	ret := fmt.Sprintf("return buffer.WithField(err, %s)", path.expr())
+
+	switch s.kind {
+	case shapeBasic:
+		_, read := g.calls(s, expr)
+		g.p("{")
+		g.p("v, err := %s", read)
+		g.p("if err != nil {")
+		g.p("%s", ret)
+		g.p("}")
+		g.p("%s = %s(v)", expr, g.typeOf(s))
+		g.p("}")
+		return
+	case shapeBytes:
+		g.p("{")
+		g.p("v, err := b.ReadByteSlice(%s)", layout(s.tag))
+		g.p("if err != nil {")
+		g.p("%s", ret)
+		g.p("}")
+		g.p("%s = append(%s(nil), v...)", expr, g.typeOf(s))
+		g.p("}")
+		return
+	case shapeSlice:
+		g.p("{")
+		g.p("n, err := b.ReadLength(%s)", layout(s.tag))
+		g.p("if err != nil {")
+		g.p("%s", ret)
+		g.p("}")
+		g.p("%s = make(%s, n)", expr, g.typeOf(s))
+		g.p("}")
+		g.decodeElements(s.elem, expr, depth, path)
+		return
+	case shapeArray:
+		g.decodeElements(s.elem, expr, depth, path)
+		return
+	case shapeByteArray:
+		g.p("if err := b.ReadFull(%s[:]); err != nil {", expr)
+	case shapeNested:
+		g.p("if err := %s.UnmarshalBinaryFrom(b); err != nil {", expr)
+	case shapeAddr:
+		g.p("if err := b.ReadAddr(&%s); err != nil {", expr)
+	}
+
+	g.p("%s", ret)
+	g.p("}")
+}
+
+// Emits a loop decoding every element of a slice or array
+func (g *generator) decodeElements(elem *shape, expr string, depth int, path fieldPath) {
+	i := fmt.Sprintf("i%d", depth)
+	g.p("for %s := range %s {", i, expr)
+	g.decode(elem, fmt.Sprintf("%s[%s]", expr, i), depth+1, path.index(i))
+	g.p("}")
+}
+
+// Emits the statements adding the encoded size of a value of the provided shape to n. Slices whose element
+// count is held by another field are counted without a length prefix.
+func (g *generator) size(s *shape, expr string, depth int, counted bool) {
+	switch s.kind {
+	case shapeBasic:
+		if n := fixedSize(s); n > 0 {
+			g.p("n += %d", n)
+			return
+		}
+
+		switch s.wire {
+		case bintag.KindVarUint32:
+			g.p("n += buffer.VarUint32Size(uint32(%s))", expr)
+		case bintag.KindVarInt32:
+			g.p("n += buffer.VarInt32Size(int32(%s))", expr)
+		case bintag.KindVarUint64:
+			g.p("n += buffer.VarUint64Size(uint64(%s))", expr)
+		case bintag.KindVarInt64:
+			g.p("n += buffer.VarInt64Size(int64(%s))", expr)
+		case bintag.KindString:
+			g.p("n += %s.Size(len(%s))", layout(s.tag), expr)
+		}
+	case shapeBytes:
+		g.p("n += %s.Size(len(%s))", layout(s.tag), expr)
+	case shapeByteArray:
+		g.p("n += len(%s)", expr)
+	case shapeNested:
+		g.p("n += %s.EncodedSize()", expr)
+	case shapeAddr:
+		g.p("n += buffer.AddrSize(&%s)", expr)
+	case shapeSlice, shapeArray:
+		if s.kind == shapeSlice && !counted {
+			g.p("n += %s.PrefixSize(len(%s))", layout(s.tag), expr)
+		}
+
+		if s.elem.kind == shapeBasic && fixedSize(s.elem) > 0 {
+			g.p("n += len(%s) * %d", expr, fixedSize(s.elem))
+			return
+		}
+
+		i := fmt.Sprintf("i%d", depth)
+		g.p("for %s := range %s {", i, expr)
+		g.size(s.elem, fmt.Sprintf("%s[%s]", expr, i), depth+1, false)
+		g.p("}")
+	}
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "update the golden files of the generator")
+
+func TestGolden(t *testing.T) {
+	inputs, err := filepath.Glob(filepath.Join("testdata", "*.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(inputs) == 0 {
+		t.Fatal("no inputs found in testdata")
+	}
+
+	for _, input := range inputs {
+		name := strings.TrimSuffix(filepath.Base(input), ".go")
+
+		t.Run(name, func(t *testing.T) {
+			p, err := parse("testdata", []string{input})
+			if err != nil {
+				t.Fatalf("parse(%s) error = %v", input, err)
+			}
+
+			got, err := generate(p, p.annotated)
+			if err != nil {
+				t.Fatalf("generate(%s) error = %v", input, err)
+			}
+
+			golden := filepath.Join("testdata", name+".golden")
+			if *update {
+				if err := os.WriteFile(golden, got, 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatalf("reading golden file: %v (run go test -update to create it)", err)
+			}
+
+			if !bytes.Equal(got, want) {
+				t.Errorf("generated code of %s does not match %s (run go test -update after reviewing the diff)\n%s", input, golden, diff(want, got))
+			}
+		})
+	}
+}
+
+// TestParityPackageUpToDate checks that the generated code the parity tests run against is the output of the
+// current generator
+func TestParityPackageUpToDate(t *testing.T) {
+	dir := filepath.Join("internal", "paritytest")
+	out := filepath.Join(dir, "binary_gen.go")
+
+	p, err := load(dir, out)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := generate(p, p.annotated)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(got, want) {
+		t.Errorf("%s is stale, run go generate ./cmd/binarygen/internal/paritytest\n%s", out, diff(want, got))
+	}
+}
+
+// Returns the first line that differs between the provided sources
+func diff(want, got []byte) string {
+	w, g := strings.Split(string(want), "\n"), strings.Split(string(got), "\n")
+
+	for i := 0; i < max(len(w), len(g)); i++ {
+		var wl, gl string
+		if i < len(w) {
+			wl = w[i]
+		}
+		if i < len(g) {
+			gl = g[i]
+		}
+
+		if wl != gl {
+			return fmt.Sprintf("line %d:\n\twant: %s\n\tgot:  %s", i+1, wl, gl)
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,609 @@
+// Code generated by binarygen. DO NOT EDIT.
+
+package paritytest
+
+import (
+	"fmt"
+	"net"
+
+	"github.com/gamevidea/binary/buffer"
+	"github.com/gamevidea/binary/byteorder"
+)
+
+// Encodes Numbers into the buffer and returns an error if the operation failed.
+func (x *Numbers) MarshalBinaryTo(b *buffer.Buffer) error {
+	if err := b.WriteUint8(uint8(x.U8)); err != nil {
+		return fmt.Errorf("U8: %w", err)
+	}
+	if err := b.WriteInt8(int8(x.I8)); err != nil {
+		return fmt.Errorf("I8: %w", err)
+	}
+	if err := b.WriteUint16(uint16(x.U16), byteorder.BigEndian); err != nil {
+		return fmt.Errorf("U16: %w", err)
+	}
+	if err := b.WriteInt16(int16(x.I16), byteorder.LittleEndian); err != nil {
+		return fmt.Errorf("I16: %w", err)
+	}
+	if err := b.WriteUint24(uint32(x.U24), byteorder.BigEndian); err != nil {
+		return fmt.Errorf("U24: %w", err)
+	}
+	if err := b.WriteUint32(uint32(x.U32), byteorder.LittleEndian); err != nil {
+		return fmt.Errorf("U32: %w", err)
+	}
+	if err := b.WriteInt32(int32(x.I32), byteorder.BigEndian); err != nil {
+		return fmt.Errorf("I32: %w", err)
+	}
+	if err := b.WriteUint64(uint64(x.U64), byteorder.BigEndian); err != nil {
+		return fmt.Errorf("U64: %w", err)
+	}
+	if err := b.WriteInt64(int64(x.I64), byteorder.LittleEndian); err != nil {
+		return fmt.Errorf("I64: %w", err)
+	}
+	if err := b.WriteFloat32(float32(x.F32), byteorder.LittleEndian); err != nil {
+		return fmt.Errorf("F32: %w", err)
+	}
+	if err := b.WriteFloat64(float64(x.F64), byteorder.BigEndian); err != nil {
+		return fmt.Errorf("F64: %w", err)
+	}
+	if err := b.WriteVarInt32(int32(x.VarI32)); err != nil {
+		return fmt.Errorf("VarI32: %w", err)
+	}
+	if err := b.WriteVarUint32(uint32(x.VarU32)); err != nil {
+		return fmt.Errorf("VarU32: %w", err)
+	}
+	if err := b.WriteVarInt64(int64(x.VarI64)); err != nil {
+		return fmt.Errorf("VarI64: %w", err)
+	}
+	if err := b.WriteVarUint64(uint64(x.VarU64)); err != nil {
+		return fmt.Errorf("VarU64: %w", err)
+	}
+	if err := b.WriteVarInt64(int64(x.Int)); err != nil {
+		return fmt.Errorf("Int: %w", err)
+	}
+	if err := b.WriteVarUint64(uint64(x.Uint)); err != nil {
+		return fmt.Errorf("Uint: %w", err)
+	}
+	if err := b.WriteBool(bool(x.Bool)); err != nil {
+		return fmt.Errorf("Bool: %w", err)
+	}
+	if err := b.WriteUint16(uint16(x.Mode), byteorder.LittleEndian); err != nil {
+		return fmt.Errorf("Mode: %w", err)
+	}
+	if err := b.WriteInt32(int32(x.Rune), byteorder.LittleEndian); err != nil {
+		return fmt.Errorf("Rune: %w", err)
+	}
+	return nil
+}
+
+// Decodes Numbers from the buffer and returns an error if the operation failed.
+func (x *Numbers) UnmarshalBinaryFrom(b *buffer.Buffer) error {
+	{
+		v, err := b.ReadUint8()
+		if err != nil {
+			return buffer.WithField(err, "U8")
+		}
+		x.U8 = uint8(v)
+	}
+	{
+		v, err := b.ReadInt8()
+		if err != nil {
+			return buffer.WithField(err, "I8")
+		}
+		x.I8 = int8(v)
+	}
+	{
+		v, err := b.ReadUint16(byteorder.BigEndian)
+		if err != nil {
+			return buffer.WithField(err, "U16")
+		}
+		x.U16 = uint16(v)
+	}
+	{
+		v, err := b.ReadInt16(byteorder.LittleEndian)
+		if err != nil {
+			return buffer.WithField(err, "I16")
+		}
+		x.I16 = int16(v)
+	}
+	{
+		v, err := b.ReadUint24(byteorder.BigEndian)
+		if err != nil {
+			return buffer.WithField(err, "U24")
+		}
+		x.U24 = uint32(v)
+	}
+	{
+		v, err := b.ReadUint32(byteorder.LittleEndian)
+		if err != nil {
+			return buffer.WithField(err, "U32")
+		}
+		x.U32 = uint32(v)
+	}
+	{
+		v, err := b.ReadInt32(byteorder.BigEndian)
+		if err != nil {
+			return buffer.WithField(err, "I32")
+		}
+		x.I32 = int32(v)
+	}
+	{
+		v, err := b.ReadUint64(byteorder.BigEndian)
+		if err != nil {
+			return buffer.WithField(err, "U64")
+		}
+		x.U64 = uint64(v)
+	}
+	{
+		v, err := b.ReadInt64(byteorder.LittleEndian)
+		if err != nil {
+			return buffer.WithField(err, "I64")
+		}
+		x.I64 = int64(v)
+	}
+	{
+		v, err := b.ReadFloat32(byteorder.LittleEndian)
+		if err != nil {
+			return buffer.WithField(err, "F32")
+		}
+		x.F32 = float32(v)
+	}
+	{
+		v, err := b.ReadFloat64(byteorder.BigEndian)
+		if err != nil {
+			return buffer.WithField(err, "F64")
+		}
+		x.F64 = float64(v)
+	}
+	{
+		v, err := b.ReadVarInt32()
+		if err != nil {
+			return buffer.WithField(err, "VarI32")
+		}
+		x.VarI32 = int32(v)
+	}
+	{
+		v, err := b.ReadVarUint32()
+		if err != nil {
+			return buffer.WithField(err, "VarU32")
+		}
+		x.VarU32 = uint32(v)
+	}
+	{
+		v, err := b.ReadVarInt64()
+		if err != nil {
+			return buffer.WithField(err, "VarI64")
+		}
+		x.VarI64 = int64(v)
+	}
+	{
+		v, err := b.ReadVarUint64()
+		if err != nil {
+			return buffer.WithField(err, "VarU64")
+		}
+		x.VarU64 = uint64(v)
+	}
+	{
+		v, err := b.ReadVarInt64()
+		if err != nil {
+			return buffer.WithField(err, "Int")
+		}
+		x.Int = int(v)
+	}
+	{
+		v, err := b.ReadVarUint64()
+		if err != nil {
+			return buffer.WithField(err, "Uint")
+		}
+		x.Uint = uint(v)
+	}
+	{
+		v, err := b.ReadBool()
+		if err != nil {
+			return buffer.WithField(err, "Bool")
+		}
+		x.Bool = bool(v)
+	}
+	{
+		v, err := b.ReadUint16(byteorder.LittleEndian)
+		if err != nil {
+			return buffer.WithField(err, "Mode")
+		}
+		x.Mode = Mode(v)
+	}
+	{
+		v, err := b.ReadInt32(byteorder.LittleEndian)
+		if err != nil {
+			return buffer.WithField(err, "Rune")
+		}
+		x.Rune = rune(v)
+	}
+	return nil
+}
+
+// Returns the number of bytes Numbers takes when encoded into a buffer.
+func (x *Numbers) EncodedSize() int {
+	n := 0
+	n += 1
+	n += 1
+	n += 2
+	n += 2
+	n += 3
+	n += 4
+	n += 4
+	n += 8
+	n += 8
+	n += 4
+	n += 8
+	n += buffer.VarInt32Size(int32(x.VarI32))
+	n += buffer.VarUint32Size(uint32(x.VarU32))
+	n += buffer.VarInt64Size(int64(x.VarI64))
+	n += buffer.VarUint64Size(uint64(x.VarU64))
+	n += buffer.VarInt64Size(int64(x.Int))
+	n += buffer.VarUint64Size(uint64(x.Uint))
+	n += 1
+	n += 2
+	n += 4
+	return n
+}
+
+// Encodes Strings into the buffer and returns an error if the operation failed.
+func (x *Strings) MarshalBinaryTo(b *buffer.Buffer) error {
+	if err := b.WriteString(string(x.Name), buffer.Layout{Prefix: buffer.PrefixVarUint32}); err != nil {
+		return fmt.Errorf("Name: %w", err)
+	}
+	if err := b.WriteString(string(x.Motd), buffer.Layout{Prefix: buffer.PrefixUint16BE, MaxLength: 64, ValidateUTF8: true}); err != nil {
+		return fmt.Errorf("Motd: %w", err)
+	}
+	if err := b.WriteByteSlice(x.Blob, buffer.Layout{Prefix: buffer.PrefixVarUint32}); err != nil {
+		return fmt.Errorf("Blob: %w", err)
+	}
+	if err := b.WriteByteSlice(x.Raw, buffer.Layout{Prefix: buffer.PrefixInt32LE}); err != nil {
+		return fmt.Errorf("Raw: %w", err)
+	}
+	if _, err := b.Write(x.Hash[:]); err != nil {
+		return fmt.Errorf("Hash: %w", err)
+	}
+	return nil
+}
+
+// Decodes Strings from the buffer and returns an error if the operation failed.
+func (x *Strings) UnmarshalBinaryFrom(b *buffer.Buffer) error {
+	{
+		v, err := b.ReadString(buffer.Layout{Prefix: buffer.PrefixVarUint32})
+		if err != nil {
+			return buffer.WithField(err, "Name")
+		}
+		x.Name = string(v)
+	}
+	{
+		v, err := b.ReadString(buffer.Layout{Prefix: buffer.PrefixUint16BE, MaxLength: 64, ValidateUTF8: true})
+		if err != nil {
+			return buffer.WithField(err, "Motd")
+		}
+		x.Motd = string(v)
+	}
+	{
+		v, err := b.ReadByteSlice(buffer.Layout{Prefix: buffer.PrefixVarUint32})
+		if err != nil {
+			return buffer.WithField(err, "Blob")
+		}
+		x.Blob = append([]byte(nil), v...)
+	}
+	{
+		v, err := b.ReadByteSlice(buffer.Layout{Prefix: buffer.PrefixInt32LE})
+		if err != nil {
+			return buffer.WithField(err, "Raw")
+		}
+		x.Raw = append([]byte(nil), v...)
+	}
+	if err := b.ReadFull(x.Hash[:]); err != nil {
+		return buffer.WithField(err, "Hash")
+	}
+	return nil
+}
+
+// Returns the number of bytes Strings takes when encoded into a buffer.
+func (x *Strings) EncodedSize() int {
+	n := 0
+	n += buffer.Layout{Prefix: buffer.PrefixVarUint32}.Size(len(x.Name))
+	n += buffer.Layout{Prefix: buffer.PrefixUint16BE, MaxLength: 64, ValidateUTF8: true}.Size(len(x.Motd))
+	n += buffer.Layout{Prefix: buffer.PrefixVarUint32}.Size(len(x.Blob))
+	n += buffer.Layout{Prefix: buffer.PrefixInt32LE}.Size(len(x.Raw))
+	n += len(x.Hash)
+	return n
+}
+
+// Encodes Collections into the buffer and returns an error if the operation failed.
+func (x *Collections) MarshalBinaryTo(b *buffer.Buffer) error {
+	if err := b.WriteLength(len(x.IDs), buffer.Layout{Prefix: buffer.PrefixVarUint32}); err != nil {
+		return fmt.Errorf("IDs: %w", err)
+	}
+	for i0 := range x.IDs {
+		if err := b.WriteVarInt32(int32(x.IDs[i0])); err != nil {
+			return fmt.Errorf("IDs: %w", err)
+		}
+	}
+	for i0 := range x.Matrix {
+		for i1 := range x.Matrix[i0] {
+			if err := b.WriteUint16(uint16(x.Matrix[i0][i1]), byteorder.LittleEndian); err != nil {
+				return fmt.Errorf("Matrix: %w", err)
+			}
+		}
+	}
+	if err := b.WriteLength(len(x.Names), buffer.Layout{Prefix: buffer.PrefixUint16LE}); err != nil {
+		return fmt.Errorf("Names: %w", err)
+	}
+	for i0 := range x.Names {
+		if err := b.WriteString(string(x.Names[i0]), buffer.Layout{Prefix: buffer.PrefixUint16LE}); err != nil {
+			return fmt.Errorf("Names: %w", err)
+		}
+	}
+	if err := b.WriteUint8(uint8(x.Count)); err != nil {
+		return fmt.Errorf("Count: %w", err)
+	}
+	if len(x.Entries) != int(x.Count) {
+		return fmt.Errorf("Entries: %w: %d elements, count %d", buffer.ErrLengthMismatch, len(x.Entries), int(x.Count))
+	}
+	for i0 := range x.Entries {
+		if err := x.Entries[i0].MarshalBinaryTo(b); err != nil {
+			return fmt.Errorf("Entries: %w", err)
+		}
+	}
+	if err := b.WriteUint16(uint16(x.Size), byteorder.LittleEndian); err != nil {
+		return fmt.Errorf("Size: %w", err)
+	}
+	if len(x.Payload) != int(x.Size) {
+		return fmt.Errorf("Payload: %w: %d elements, count %d", buffer.ErrLengthMismatch, len(x.Payload), int(x.Size))
+	}
+	if _, err := b.Write(x.Payload); err != nil {
+		return fmt.Errorf("Payload: %w", err)
+	}
+	if err := x.Nested.MarshalBinaryTo(b); err != nil {
+		return fmt.Errorf("Nested: %w", err)
+	}
+	if err := b.WriteAddr(&x.Addr); err != nil {
+		return fmt.Errorf("Addr: %w", err)
+	}
+	if err := b.WriteLength(len(x.Peers), buffer.Layout{Prefix: buffer.PrefixUint16BE}); err != nil {
+		return fmt.Errorf("Peers: %w", err)
+	}
+	for i0 := range x.Peers {
+		if err := b.WriteAddr(&x.Peers[i0]); err != nil {
+			return fmt.Errorf("Peers: %w", err)
+		}
+	}
+	return nil
+}
+
+// Decodes Collections from the buffer and returns an error if the operation failed.
+func (x *Collections) UnmarshalBinaryFrom(b *buffer.Buffer) error {
+	{
+		n, err := b.ReadLength(buffer.Layout{Prefix: buffer.PrefixVarUint32})
+		if err != nil {
+			return buffer.WithField(err, "IDs")
+		}
+		x.IDs = make([]int32, n)
+	}
+	for i0 := range x.IDs {
+		{
+			v, err := b.ReadVarInt32()
+			if err != nil {
+				return buffer.WithField(err, fmt.Sprintf("IDs[%d]", i0))
+			}
+			x.IDs[i0] = int32(v)
+		}
+	}
+	for i0 := range x.Matrix {
+		for i1 := range x.Matrix[i0] {
+			{
+				v, err := b.ReadUint16(byteorder.LittleEndian)
+				if err != nil {
+					return buffer.WithField(err, fmt.Sprintf("Matrix[%d][%d]", i0, i1))
+				}
+				x.Matrix[i0][i1] = uint16(v)
+			}
+		}
+	}
+	{
+		n, err := b.ReadLength(buffer.Layout{Prefix: buffer.PrefixUint16LE})
+		if err != nil {
+			return buffer.WithField(err, "Names")
+		}
+		x.Names = make([]string, n)
+	}
+	for i0 := range x.Names {
+		{
+			v, err := b.ReadString(buffer.Layout{Prefix: buffer.PrefixUint16LE})
+			if err != nil {
+				return buffer.WithField(err, fmt.Sprintf("Names[%d]", i0))
+			}
+			x.Names[i0] = string(v)
+		}
+	}
+	{
+		v, err := b.ReadUint8()
+		if err != nil {
+			return buffer.WithField(err, "Count")
+		}
+		x.Count = uint8(v)
+	}
+	{
+		n := int(x.Count)
+		if n < 0 {
+			return buffer.WithField(&buffer.DecodeError{Offset: b.Offset(), Remaining: b.Remaining(), Err: buffer.ErrInvalidLength}, "Entries")
+		}
+		if n > b.Remaining() {
+			return buffer.WithField(&buffer.DecodeError{Offset: b.Offset(), Size: n, Remaining: b.Remaining(), Err: buffer.ErrEndOfFile}, "Entries")
+		}
+		x.Entries = make([]Entry, n)
+	}
+	for i0 := range x.Entries {
+		if err := x.Entries[i0].UnmarshalBinaryFrom(b); err != nil {
+			return buffer.WithField(err, fmt.Sprintf("Entries[%d]", i0))
+		}
+	}
+	{
+		v, err := b.ReadUint16(byteorder.LittleEndian)
+		if err != nil {
+			return buffer.WithField(err, "Size")
+		}
+		x.Size = uint16(v)
+	}
+	{
+		n := int(x.Size)
+		if n < 0 {
+			return buffer.WithField(&buffer.DecodeError{Offset: b.Offset(), Remaining: b.Remaining(), Err: buffer.ErrInvalidLength}, "Payload")
+		}
+		if n > b.Remaining() {
+			return buffer.WithField(&buffer.DecodeError{Offset: b.Offset(), Size: n, Remaining: b.Remaining(), Err: buffer.ErrEndOfFile}, "Payload")
+		}
+		x.Payload = make([]byte, n)
+		if err := b.ReadFull(x.Payload); err != nil {
+			return buffer.WithField(err, "Payload")
+		}
+	}
+	if err := x.Nested.UnmarshalBinaryFrom(b); err != nil {
+		return buffer.WithField(err, "Nested")
+	}
+	if err := b.ReadAddr(&x.Addr); err != nil {
+		return buffer.WithField(err, "Addr")
+	}
+	{
+		n, err := b.ReadLength(buffer.Layout{Prefix: buffer.PrefixUint16BE})
+		if err != nil {
+			return buffer.WithField(err, "Peers")
+		}
+		x.Peers = make([]net.UDPAddr, n)
+	}
+	for i0 := range x.Peers {
+		if err := b.ReadAddr(&x.Peers[i0]); err != nil {
+			return buffer.WithField(err, fmt.Sprintf("Peers[%d]", i0))
+		}
+	}
+	return nil
+}
+
+// Returns the number of bytes Collections takes when encoded into a buffer.
+func (x *Collections) EncodedSize() int {
+	n := 0
+	n += buffer.Layout{Prefix: buffer.PrefixVarUint32}.PrefixSize(len(x.IDs))
+	for i0 := range x.IDs {
+		n += buffer.VarInt32Size(int32(x.IDs[i0]))
+	}
+	for i0 := range x.Matrix {
+		n += len(x.Matrix[i0]) * 2
+	}
+	n += buffer.Layout{Prefix: buffer.PrefixUint16LE}.PrefixSize(len(x.Names))
+	for i0 := range x.Names {
+		n += buffer.Layout{Prefix: buffer.PrefixUint16LE}.Size(len(x.Names[i0]))
+	}
+	n += 1
+	for i0 := range x.Entries {
+		n += x.Entries[i0].EncodedSize()
+	}
+	n += 2
+	n += len(x.Payload)
+	n += x.Nested.EncodedSize()
+	n += buffer.AddrSize(&x.Addr)
+	n += buffer.Layout{Prefix: buffer.PrefixUint16BE}.PrefixSize(len(x.Peers))
+	for i0 := range x.Peers {
+		n += buffer.AddrSize(&x.Peers[i0])
+	}
+	return n
+}
+
+// Encodes Custom into the buffer and returns an error if the operation failed.
+func (x *Custom) MarshalBinaryTo(b *buffer.Buffer) error {
+	if err := b.WriteInt32(int32(x.Protocol), byteorder.BigEndian); err != nil {
+		return fmt.Errorf("Protocol: %w", err)
+	}
+	if err := x.Version.MarshalBinaryTo(b); err != nil {
+		return fmt.Errorf("Version: %w", err)
+	}
+	if err := b.WriteLength(len(x.Versions), buffer.Layout{Prefix: buffer.PrefixVarUint32}); err != nil {
+		return fmt.Errorf("Versions: %w", err)
+	}
+	for i0 := range x.Versions {
+		if err := x.Versions[i0].MarshalBinaryTo(b); err != nil {
+			return fmt.Errorf("Versions: %w", err)
+		}
+	}
+	return nil
+}
+
+// Decodes Custom from the buffer and returns an error if the operation failed.
+func (x *Custom) UnmarshalBinaryFrom(b *buffer.Buffer) error {
+	{
+		v, err := b.ReadInt32(byteorder.BigEndian)
+		if err != nil {
+			return buffer.WithField(err, "Protocol")
+		}
+		x.Protocol = int32(v)
+	}
+	if err := x.Version.UnmarshalBinaryFrom(b); err != nil {
+		return buffer.WithField(err, "Version")
+	}
+	{
+		n, err := b.ReadLength(buffer.Layout{Prefix: buffer.PrefixVarUint32})
+		if err != nil {
+			return buffer.WithField(err, "Versions")
+		}
+		x.Versions = make([]Version, n)
+	}
+	for i0 := range x.Versions {
+		if err := x.Versions[i0].UnmarshalBinaryFrom(b); err != nil {
+			return buffer.WithField(err, fmt.Sprintf("Versions[%d]", i0))
+		}
+	}
+	return nil
+}
+
+// Returns the number of bytes Custom takes when encoded into a buffer.
+func (x *Custom) EncodedSize() int {
+	n := 0
+	n += 4
+	n += x.Version.EncodedSize()
+	n += buffer.Layout{Prefix: buffer.PrefixVarUint32}.PrefixSize(len(x.Versions))
+	for i0 := range x.Versions {
+		n += x.Versions[i0].EncodedSize()
+	}
+	return n
+}
+
+// Encodes Entry into the buffer and returns an error if the operation failed.
+func (x *Entry) MarshalBinaryTo(b *buffer.Buffer) error {
+	if err := b.WriteUint16(uint16(x.ID), byteorder.BigEndian); err != nil {
+		return fmt.Errorf("ID: %w", err)
+	}
+	if err := b.WriteString(string(x.Label), buffer.Layout{Prefix: buffer.PrefixVarUint32}); err != nil {
+		return fmt.Errorf("Label: %w", err)
+	}
+	return nil
+}
+
+// Decodes Entry from the buffer and returns an error if the operation failed.
+func (x *Entry) UnmarshalBinaryFrom(b *buffer.Buffer) error {
+	{
+		v, err := b.ReadUint16(byteorder.BigEndian)
+		if err != nil {
+			return buffer.WithField(err, "ID")
+		}
+		x.ID = uint16(v)
+	}
+	{
+		v, err := b.ReadString(buffer.Layout{Prefix: buffer.PrefixVarUint32})
+		if err != nil {
+			return buffer.WithField(err, "Label")
+		}
+		x.Label = string(v)
+	}
+	return nil
+}
+
+// Returns the number of bytes Entry takes when encoded into a buffer.
+func (x *Entry) EncodedSize() int {
+	n := 0
+	n += 2
+	n += buffer.Layout{Prefix: buffer.PrefixVarUint32}.Size(len(x.Label))
+	return n
+}
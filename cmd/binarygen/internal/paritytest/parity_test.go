@@ -0,0 +1,152 @@
+package paritytest
+
+import (
+	"bytes"
+	"math"
+	"net"
+	"testing"
+
+	"github.com/gamevidea/binary/buffer"
+)
+
+// The plain types have the same fields as the generated ones but none of their methods, so that buffer.Marshal
+// encodes them by reflection instead of calling the generated code.
+type (
+	plainNumbers     Numbers
+	plainStrings     Strings
+	plainCollections Collections
+	plainCustom      Custom
+	plainEntry       Entry
+)
+
+// generated is implemented by the structs binarygen generated codecs for
+type generated interface {
+	buffer.Codec
+	EncodedSize() int
+}
+
+// Checks that the generated codec of v encodes to the same bytes as buffer.Marshal encodes plain to, that its
+// EncodedSize is the encoded length and that both decoders read the bytes back to values encoding the same way
+func checkParity(t *testing.T, v generated, plain any, decoded generated, plainDecoded any) {
+	t.Helper()
+
+	want, err := buffer.Marshal(plain)
+	if err != nil {
+		t.Fatalf("buffer.Marshal() error = %v", err)
+	}
+
+	b := buffer.NewGrowable(0)
+	if err := v.MarshalBinaryTo(b); err != nil {
+		t.Fatalf("MarshalBinaryTo() error = %v", err)
+	}
+
+	got := b.Bytes()
+	if !bytes.Equal(got, want) {
+		t.Fatalf("MarshalBinaryTo() = %x, buffer.Marshal() = %x", got, want)
+	}
+
+	if n := v.EncodedSize(); n != len(got) {
+		t.Fatalf("EncodedSize() = %d, encoded %d bytes", n, len(got))
+	}
+
+	r := buffer.From(got)
+	if err := decoded.UnmarshalBinaryFrom(r); err != nil || r.Remaining() != 0 {
+		t.Fatalf("UnmarshalBinaryFrom() error = %v with %d bytes left", err, r.Remaining())
+	}
+
+	b = buffer.NewGrowable(0)
+	if err := decoded.MarshalBinaryTo(b); err != nil || !bytes.Equal(b.Bytes(), want) {
+		t.Fatalf("re-encoding the generated decoder's value = %x, %v, want %x", b.Bytes(), err, want)
+	}
+
+	r = buffer.From(got)
+	if err := buffer.Unmarshal(r, plainDecoded); err != nil || r.Remaining() != 0 {
+		t.Fatalf("buffer.Unmarshal() error = %v with %d bytes left", err, r.Remaining())
+	}
+
+	again, err := buffer.Marshal(plainDecoded)
+	if err != nil || !bytes.Equal(again, want) {
+		t.Fatalf("re-encoding the reflection decoder's value = %x, %v, want %x", again, err, want)
+	}
+}
+
+func TestNumbersParity(t *testing.T) {
+	values := []Numbers{
+		{},
+		{
+			U8: math.MaxUint8, I8: math.MinInt8, U16: 0xbeef, I16: math.MinInt16, U24: 0xabcdef, U32: math.MaxUint32,
+			I32: math.MinInt32, U64: math.MaxUint64, I64: math.MinInt64, F32: math.Pi, F64: -math.E,
+			VarI32: math.MinInt32, VarU32: math.MaxUint32, VarI64: math.MinInt64, VarU64: math.MaxUint64,
+			Int: -300, Uint: 1 << 20, Bool: true, Mode: 0x1234, Rune: '§',
+		},
+	}
+
+	for _, v := range values {
+		plain := plainNumbers(v)
+		checkParity(t, &v, &plain, new(Numbers), new(plainNumbers))
+	}
+}
+
+func TestStringsParity(t *testing.T) {
+	values := []Strings{
+		{},
+		{
+			Name: "steve", Motd: "a minecraft server §a", Blob: []byte{1, 2, 3}, Raw: bytes.Repeat([]byte{0xff}, 300),
+			Hash: [4]byte{0xde, 0xad, 0xbe, 0xef}, Ignored: "not encoded",
+		},
+	}
+
+	for _, v := range values {
+		plain := plainStrings(v)
+		checkParity(t, &v, &plain, new(Strings), new(plainStrings))
+	}
+}
+
+func TestCollectionsParity(t *testing.T) {
+	values := []Collections{
+		{Addr: net.UDPAddr{IP: net.IPv4zero.To4()}},
+		{
+			IDs:     []int32{-1, 0, math.MaxInt32},
+			Matrix:  [2][3]uint16{{1, 2, 3}, {4, 5, 6}},
+			Names:   []string{"", "alex", "steve"},
+			Count:   2,
+			Entries: []Entry{{ID: 1, Label: "one"}, {ID: 2, Label: "two"}},
+			Size:    4,
+			Payload: []byte{9, 8, 7, 6},
+			Nested:  Entry{ID: 0xffff, Label: "nested"},
+			Addr:    net.UDPAddr{IP: net.IPv4(127, 0, 0, 1).To4(), Port: 19132},
+			Peers: []net.UDPAddr{
+				{IP: net.IPv4(10, 0, 0, 1).To4(), Port: 1},
+				{IP: net.ParseIP("2001:db8::1"), Port: 19133},
+			},
+		},
+	}
+
+	for _, v := range values {
+		plain := plainCollections(v)
+		checkParity(t, &v, &plain, new(Collections), new(plainCollections))
+	}
+
+	entry := Entry{ID: 7, Label: "entry"}
+	plain := plainEntry(entry)
+	checkParity(t, &entry, &plain, new(Entry), new(plainEntry))
+}
+
+func TestCustomParity(t *testing.T) {
+	v := Custom{Protocol: 766, Version: Version{Major: 1, Minor: 21}, Versions: []Version{{1, 20}, {1, 19}}}
+	plain := plainCustom(v)
+	checkParity(t, &v, &plain, new(Custom), new(plainCustom))
+}
+
+func TestCountMismatch(t *testing.T) {
+	v := Collections{Count: 3, Entries: []Entry{{ID: 1}}}
+
+	if err := v.MarshalBinaryTo(buffer.NewGrowable(0)); err == nil {
+		t.Fatal("MarshalBinaryTo() succeeded with a count that does not match the slice")
+	}
+
+	plain := plainCollections(v)
+	if _, err := buffer.Marshal(&plain); err == nil {
+		t.Fatal("buffer.Marshal() succeeded with a count that does not match the slice")
+	}
+}
@@ -0,0 +1,103 @@
+// Package paritytest holds structs covering every encoding supported by binarygen together with their generated
+// codecs, so that the generated code can be checked against buffer.Marshal byte for byte.
+package paritytest
+
+//go:generate go run github.com/gamevidea/binary/cmd/binarygen
+
+import (
+	"net"
+
+	"github.com/gamevidea/binary/buffer"
+)
+
+// Mode is a named integer encoded as its underlying type
+type Mode uint16
+
+//binary:codec
+type Numbers struct {
+	U8     uint8
+	I8     int8
+	U16    uint16 `bin:"u16,be"`
+	I16    int16
+	U24    uint32 `bin:"u24,be"`
+	U32    uint32
+	I32    int32  `bin:"i32,be"`
+	U64    uint64 `bin:"u64,be"`
+	I64    int64
+	F32    float32
+	F64    float64 `bin:"f64,be"`
+	VarI32 int32   `bin:"varint"`
+	VarU32 uint32  `bin:"varuint"`
+	VarI64 int64   `bin:"varint"`
+	VarU64 uint64  `bin:"varuint"`
+	Int    int     `bin:"varint"`
+	Uint   uint    `bin:"varuint"`
+	Bool   bool
+	Mode   Mode
+	Rune   rune
+}
+
+//binary:codec
+type Strings struct {
+	Name    string
+	Motd    string `bin:"string,prefix=u16be,max=64,utf8"`
+	Blob    []byte
+	Raw     []byte `bin:"bytes,prefix=i32le"`
+	Hash    [4]byte
+	Ignored string `bin:"-"`
+	hidden  int
+}
+
+// Entry is not annotated but is generated as it is used as a field
+type Entry struct {
+	ID    uint16 `bin:"u16,be"`
+	Label string
+}
+
+//binary:codec
+type Collections struct {
+	IDs     []int32 `bin:"varint"`
+	Matrix  [2][3]uint16
+	Names   []string `bin:"string,prefix=u16le"`
+	Count   uint8
+	Entries []Entry `bin:"len=Count"`
+	Size    uint16
+	Payload []byte `bin:"len=Size"`
+	Nested  Entry
+	Addr    net.UDPAddr
+	Peers   []net.UDPAddr `bin:"addr,prefix=u16be"`
+}
+
+// Version implements buffer.Codec by hand, so it is encoded by its own methods
+type Version struct {
+	Major, Minor uint8
+}
+
+func (v *Version) MarshalBinaryTo(b *buffer.Buffer) error {
+	if err := b.WriteUint8(v.Major); err != nil {
+		return err
+	}
+
+	return b.WriteUint8(v.Minor)
+}
+
+func (v *Version) UnmarshalBinaryFrom(b *buffer.Buffer) error {
+	var err error
+	if v.Major, err = b.ReadUint8(); err != nil {
+		return err
+	}
+
+	v.Minor, err = b.ReadUint8()
+	return err
+}
+
+func (v *Version) EncodedSize() int {
+	return 2
+}
+
+//binary:codec
+type Custom struct {
+	Protocol int32 `bin:"i32,be"`
+	Version  Version
+	Versions []Version
+}
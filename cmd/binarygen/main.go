@@ -0,0 +1,222 @@
+// Command binarygen generates zero-reflection codecs for the structs of a package.
+//
+// For every struct annotated with a //binary:codec directive, or listed with the -type flag, it emits
+// MarshalBinaryTo, UnmarshalBinaryFrom and EncodedSize methods that call the Read* and Write* methods of
+// buffer.Buffer directly. The `bin` struct tags are interpreted exactly like buffer.Marshal and buffer.Unmarshal
+// do, so the generated code produces the very same bytes as the reflection path. Structs of the same package that
+// are used as fields are generated as well.
+//
+// Typical usage is a go:generate directive next to the annotated structs:
+//
+//	//go:generate go run github.com/gamevidea/binary/cmd/binarygen
+//
+//	//binary:codec
+//	type UnconnectedPing struct {
+//		Time int64 `bin:"i64,be"`
+//		GUID int64 `bin:"i64,be"`
+//	}
+package main
+
+import (
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"log"
+	"os"
+	pathpkg "path"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// directive is the comment that marks a struct for code generation
+const directive = "//binary:codec"
+
+var (
+	typeNames = flag.String("type", "", "comma separated list of struct names; defaults to the structs annotated with "+directive)
+	output    = flag.String("output", "", "output file name; defaults to binary_gen.go in the package directory")
+)
+
+// pkg is a parsed package whose structs are generated
+type pkg struct {
+	name string
+	// types maps the name of every type declared in the package to its specification
+	types map[string]*ast.TypeSpec
+	// annotated is the list of structs annotated with the directive in declaration order
+	annotated []string
+	// imports maps the names of the packages imported by the package's files to their import paths
+	imports map[string]string
+	// methods maps the name of every type declared in the package to the names of its methods
+	methods map[string]map[string]bool
+}
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("binarygen: ")
+
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: binarygen [-type T,U] [-output file] [directory]\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	dir := "."
+	if flag.NArg() > 0 {
+		dir = flag.Arg(0)
+	}
+
+	out := *output
+	if out == "" {
+		out = filepath.Join(dir, "binary_gen.go")
+	}
+
+	p, err := load(dir, out)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	names := p.annotated
+	if *typeNames != "" {
+		names = strings.Split(*typeNames, ",")
+	}
+
+	if len(names) == 0 {
+		log.Fatalf("no structs annotated with %s found in %s", directive, dir)
+	}
+
+	src, err := generate(p, names)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if err := os.WriteFile(out, src, 0o644); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// Parses the Go files of the package in the provided directory, skipping tests and the output file
+func load(dir, out string) (*pkg, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.go"))
+	if err != nil {
+		return nil, err
+	}
+
+	outAbs, err := filepath.Abs(out)
+	if err != nil {
+		return nil, err
+	}
+
+	var sources []string
+	for _, file := range files {
+		if strings.HasSuffix(file, "_test.go") {
+			continue
+		}
+
+		if abs, err := filepath.Abs(file); err == nil && abs == outAbs {
+			continue
+		}
+
+		sources = append(sources, file)
+	}
+
+	return parse(dir, sources)
+}
+
+// Parses the provided Go files, which must belong to the same package in the provided directory
+func parse(dir string, files []string) (*pkg, error) {
+	p := &pkg{
+		types:   make(map[string]*ast.TypeSpec),
+		imports: make(map[string]string),
+		methods: make(map[string]map[string]bool),
+	}
+	fset := token.NewFileSet()
+
+	for _, file := range files {
+		f, err := parser.ParseFile(fset, file, nil, parser.ParseComments)
+		if err != nil {
+			return nil, err
+		}
+
+		if p.name == "" {
+			p.name = f.Name.Name
+		} else if p.name != f.Name.Name {
+			return nil, fmt.Errorf("multiple packages in %s: %s and %s", dir, p.name, f.Name.Name)
+		}
+
+		for _, imp := range f.Imports {
+			path, _ := strconv.Unquote(imp.Path.Value)
+
+			name := pathpkg.Base(path)
+			if imp.Name != nil {
+				name = imp.Name.Name
+			}
+			p.imports[name] = path
+		}
+
+		for _, decl := range f.Decls {
+			if fn, ok := decl.(*ast.FuncDecl); ok && fn.Recv != nil && len(fn.Recv.List) == 1 {
+				recv := fn.Recv.List[0].Type
+				if star, ok := recv.(*ast.StarExpr); ok {
+					recv = star.X
+				}
+
+				if ident, ok := recv.(*ast.Ident); ok {
+					if p.methods[ident.Name] == nil {
+						p.methods[ident.Name] = make(map[string]bool)
+					}
+					p.methods[ident.Name][fn.Name.Name] = true
+				}
+
+				continue
+			}
+
+			gen, ok := decl.(*ast.GenDecl)
+			if !ok || gen.Tok != token.TYPE {
+				continue
+			}
+
+			for _, spec := range gen.Specs {
+				ts := spec.(*ast.TypeSpec)
+				p.types[ts.Name.Name] = ts
+
+				doc := ts.Doc
+				if doc == nil && len(gen.Specs) == 1 {
+					doc = gen.Doc
+				}
+
+				if _, ok := ts.Type.(*ast.StructType); ok && annotated(doc) {
+					p.annotated = append(p.annotated, ts.Name.Name)
+				}
+			}
+		}
+	}
+
+	if p.name == "" {
+		return nil, fmt.Errorf("no Go files found in %s", dir)
+	}
+
+	return p, nil
+}
+
+// Reports whether the named type declares the methods of buffer.Codec by hand
+func (p *pkg) codec(name string) bool {
+	m := p.methods[name]
+	return m["MarshalBinaryTo"] && m["UnmarshalBinaryFrom"]
+}
+
+// Reports whether the provided doc comment contains the code generation directive
+func annotated(doc *ast.CommentGroup) bool {
+	if doc == nil {
+		return false
+	}
+
+	for _, c := range doc.List {
+		if strings.TrimSpace(c.Text) == directive {
+			return true
+		}
+	}
+
+	return false
+}
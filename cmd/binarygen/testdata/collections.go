@@ -0,0 +1,23 @@
+package testdata
+
+import "net"
+
+// Entry is not annotated but is generated as it is used as a field
+type Entry struct {
+	ID    uint16 `bin:"u16,be"`
+	Label string
+}
+
+//binary:codec
+type Collections struct {
+	IDs     []int32 `bin:"varint"`
+	Matrix  [2][3]uint16
+	Names   []string `bin:"string,prefix=u16le"`
+	Count   uint8
+	Entries []Entry `bin:"len=Count"`
+	Size    uint16
+	Payload []byte `bin:"len=Size"`
+	Nested  Entry
+	Addr    net.UDPAddr
+	Peers   []net.UDPAddr `bin:"addr,prefix=u16be"`
+}
@@ -0,0 +1,37 @@
+package testdata
+
+import "github.com/gamevidea/binary/buffer"
+
+// Version implements buffer.Codec by hand, so it is encoded by its own methods
+type Version struct {
+	Major, Minor uint8
+}
+
+func (v *Version) MarshalBinaryTo(b *buffer.Buffer) error {
+	if err := b.WriteUint8(v.Major); err != nil {
+		return err
+	}
+
+	return b.WriteUint8(v.Minor)
+}
+
+func (v *Version) UnmarshalBinaryFrom(b *buffer.Buffer) error {
+	var err error
+	if v.Major, err = b.ReadUint8(); err != nil {
+		return err
+	}
+
+	v.Minor, err = b.ReadUint8()
+	return err
+}
+
+func (v *Version) EncodedSize() int {
+	return 2
+}
+
+//binary:codec
+type Custom struct {
+	Protocol int32 `bin:"i32,be"`
+	Version  Version
+	Versions []Version
+}
@@ -0,0 +1,27 @@
+package testdata
+
+// Mode is a named integer encoded as its underlying type
+type Mode uint16
+
+//binary:codec
+type Numbers struct {
+	U8     uint8
+	I8     int8
+	U16    uint16 `bin:"u16,be"`
+	I16    int16
+	U24    uint32 `bin:"u24,be"`
+	U32    uint32
+	I32    int32  `bin:"i32,be"`
+	U64    uint64 `bin:"u64,be"`
+	I64    int64
+	F32    float32
+	F64    float64 `bin:"f64,be"`
+	VarI32 int32   `bin:"varint"`
+	VarU32 uint32  `bin:"varuint"`
+	VarI64 int64   `bin:"varint"`
+	VarU64 uint64  `bin:"varuint"`
+	Int    int     `bin:"varint"`
+	Uint   uint    `bin:"varuint"`
+	Bool   bool
+	Mode   Mode
+}
@@ -0,0 +1,12 @@
+package testdata
+
+//binary:codec
+type Strings struct {
+	Name    string
+	Motd    string `bin:"string,prefix=u16be,max=64,utf8"`
+	Blob    []byte
+	Raw     []byte `bin:"bytes,prefix=i32le"`
+	Hash    [4]byte
+	Ignored string `bin:"-"`
+	hidden  int
+}
@@ -0,0 +1,198 @@
+package compression
+
+import (
+	"bytes"
+	"compress/flate"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/gamevidea/binary/buffer"
+	"github.com/golang/snappy"
+)
+
+var (
+	// Flate is raw deflate at the default compression level
+	Flate Algorithm = NewFlate(flate.DefaultCompression)
+	// Snappy is the snappy block format
+	Snappy Algorithm = &snappyAlgorithm{}
+	// None writes payloads as is
+	None Algorithm = noneAlgorithm{}
+)
+
+// flateAlgorithm is raw deflate with pooled writers and readers, as allocating their state is expensive
+type flateAlgorithm struct {
+	level   int
+	writers sync.Pool
+	readers sync.Pool
+}
+
+// flateReader is the pooled state of a raw deflate decompression
+type flateReader struct {
+	src     bytes.Reader
+	r       io.ReadCloser
+	limited io.LimitedReader
+}
+
+// Creates and returns raw deflate at the provided compression level, which is one of the levels of
+// compress/flate.
+func NewFlate(level int) Algorithm {
+	return &flateAlgorithm{level: level}
+}
+
+// Writes the raw deflate compressed src to dst and returns an error if the operation was unsuccessful, in
+// which case dst is left as it was
+func (a *flateAlgorithm) Compress(dst *buffer.Buffer, src []byte) (err error) {
+	defer rollback(dst, dst.Mark(), &err)
+
+	w, _ := a.writers.Get().(*flate.Writer)
+	if w == nil {
+		var err error
+		if w, err = flate.NewWriter(dst, a.level); err != nil {
+			return err
+		}
+	} else {
+		w.Reset(dst)
+	}
+	defer func() {
+		w.Reset(io.Discard)
+		a.writers.Put(w)
+	}()
+
+	if _, err := w.Write(src); err != nil {
+		return err
+	}
+
+	return w.Close()
+}
+
+// Writes the raw deflate decompressed src to dst and returns an error if the operation was unsuccessful, in
+// which case dst is left as it was. ErrTooLarge is returned if src decompresses to more than max bytes.
+func (a *flateAlgorithm) Decompress(dst *buffer.Buffer, src []byte, max int) (err error) {
+	defer rollback(dst, dst.Mark(), &err)
+
+	fr, _ := a.readers.Get().(*flateReader)
+	if fr == nil {
+		fr = &flateReader{}
+		fr.src.Reset(src)
+		fr.r = flate.NewReader(&fr.src)
+	} else {
+		fr.src.Reset(src)
+		if err := fr.r.(flate.Resetter).Reset(&fr.src, nil); err != nil {
+			return err
+		}
+	}
+	defer func() {
+		fr.src.Reset(nil)
+		a.readers.Put(fr)
+	}()
+
+	fr.limited = io.LimitedReader{R: fr.r, N: int64(max) + 1}
+
+	n, err := dst.ReadFrom(&fr.limited)
+	if err != nil {
+		if errors.Is(err, buffer.ErrEndOfFile) {
+			return err
+		}
+
+		return fmt.Errorf("%w: %w", ErrCorrupt, err)
+	}
+
+	if n > int64(max) {
+		return ErrTooLarge
+	}
+
+	return nil
+}
+
+// snappyAlgorithm is the snappy block format with pooled scratch slices, as snappy can neither encode into
+// nor decode from a buffer directly
+type snappyAlgorithm struct {
+	scratch sync.Pool
+}
+
+// Writes the snappy compressed src to dst and returns an error if the operation was unsuccessful, in which
+// case dst is left as it was
+func (a *snappyAlgorithm) Compress(dst *buffer.Buffer, src []byte) (err error) {
+	defer rollback(dst, dst.Mark(), &err)
+
+	s := a.get(snappy.MaxEncodedLen(len(src)))
+	defer a.scratch.Put(s)
+
+	_, err = dst.Write(snappy.Encode(*s, src))
+	return err
+}
+
+// Writes the snappy decompressed src to dst and returns an error if the operation was unsuccessful, in which
+// case dst is left as it was. ErrTooLarge is returned if src decompresses to more than max bytes.
+func (a *snappyAlgorithm) Decompress(dst *buffer.Buffer, src []byte, max int) (err error) {
+	defer rollback(dst, dst.Mark(), &err)
+
+	n, err := snappy.DecodedLen(src)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrCorrupt, err)
+	}
+
+	if n > max {
+		return ErrTooLarge
+	}
+
+	s := a.get(n)
+	defer a.scratch.Put(s)
+
+	v, err := snappy.Decode(*s, src)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrCorrupt, err)
+	}
+
+	_, err = dst.Write(v)
+	return err
+}
+
+// Returns a pooled scratch slice of at least n bytes
+func (a *snappyAlgorithm) get(n int) *[]byte {
+	s, _ := a.scratch.Get().(*[]byte)
+	if s == nil {
+		s = new([]byte)
+	}
+
+	if cap(*s) < n {
+		*s = make([]byte, n)
+	}
+
+	*s = (*s)[:cap(*s)]
+	return s
+}
+
+// noneAlgorithm writes payloads as is
+type noneAlgorithm struct{}
+
+// Writes src to dst as is and returns an error if the operation was unsuccessful, in which case dst is left
+// as it was
+func (noneAlgorithm) Compress(dst *buffer.Buffer, src []byte) (err error) {
+	defer rollback(dst, dst.Mark(), &err)
+
+	_, err = dst.Write(src)
+	return err
+}
+
+// Writes src to dst as is and returns an error if the operation was unsuccessful, in which case dst is left
+// as it was. ErrTooLarge is returned if src is longer than max bytes.
+func (noneAlgorithm) Decompress(dst *buffer.Buffer, src []byte, max int) (err error) {
+	defer rollback(dst, dst.Mark(), &err)
+
+	if len(src) > max {
+		return ErrTooLarge
+	}
+
+	_, err = dst.Write(src)
+	return err
+}
+
+// Rewinds dst to the provided mark if the operation failed, so that nothing partially written is left behind
+func rollback(dst *buffer.Buffer, m buffer.Mark, err *error) {
+	if *err != nil {
+		dst.Rewind(m)
+	}
+}
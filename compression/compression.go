@@ -0,0 +1,136 @@
+// Package compression implements the compression of bedrock batches. Since the network settings packet
+// negotiates it, every batch starts with a byte identifying the algorithm its payload is compressed with.
+package compression
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/gamevidea/binary/buffer"
+)
+
+// IDs of the algorithms written before every compressed payload
+const (
+	IDFlate  uint8 = 0x00
+	IDSnappy uint8 = 0x01
+	IDNone   uint8 = 0xff
+)
+
+// DefaultMaxDecompressedSize is the maximum size of a decompressed payload when no limit is configured
+const DefaultMaxDecompressedSize = 16 << 20
+
+// ErrUnknownAlgorithm is the error returned when a payload is compressed with an algorithm that is not
+// registered
+var ErrUnknownAlgorithm = errors.New("could not process the payload as its compression algorithm is unknown")
+
+// ErrTooLarge is the error returned when a payload decompresses to more bytes than allowed
+var ErrTooLarge = errors.New("could not decompress the payload as it exceeds the maximum size")
+
+// ErrCorrupt is the error returned when a payload could not be decompressed by its algorithm
+var ErrCorrupt = errors.New("could not decompress the payload as it is corrupt")
+
+// Algorithm compresses and decompresses payloads. Implementations must be safe for concurrent use.
+type Algorithm interface {
+	// Compress writes the compressed src to dst, leaving dst as it was if it fails
+	Compress(dst *buffer.Buffer, src []byte) error
+	// Decompress writes the decompressed src to dst, failing with ErrTooLarge if it exceeds max bytes. dst
+	// is left as it was if it fails.
+	Decompress(dst *buffer.Buffer, src []byte, max int) error
+}
+
+// algorithms are the registered algorithms indexed by their id
+var algorithms = map[uint8]Algorithm{
+	IDFlate:  Flate,
+	IDSnappy: Snappy,
+	IDNone:   None,
+}
+
+// Registers the algorithm with the provided id, replacing any algorithm registered before, such as Flate
+// with NewFlate of another level. It must be called before any payload is compressed or decompressed, such
+// as from an init function.
+func Register(id uint8, a Algorithm) {
+	algorithms[id] = a
+}
+
+// Returns the algorithm with the provided id and reports whether it is registered
+func Lookup(id uint8) (Algorithm, bool) {
+	a, ok := algorithms[id]
+	return a, ok
+}
+
+// Compressor compresses and decompresses batch payloads preceded by the id of their algorithm. The source
+// and destination buffers passed to its methods must be distinct.
+type Compressor struct {
+	// ID is the id of the algorithm payloads are compressed with
+	ID uint8
+	// Threshold is the size below which payloads are not compressed and written with IDNone instead, as
+	// compressing them costs more than it saves
+	Threshold int
+	// MaxDecompressedSize is the maximum size of a decompressed payload. Zero means
+	// DefaultMaxDecompressedSize.
+	MaxDecompressedSize int
+}
+
+// Compresses the bytes left in src and writes them to dst preceded by the id of the algorithm, which is
+// IDNone if they are smaller than the threshold. src is drained on success, while dst is left as it was on
+// failure.
+func (c Compressor) Compress(dst, src *buffer.Buffer) (err error) {
+	id := c.ID
+	if src.Remaining() < c.Threshold {
+		id = IDNone
+	}
+
+	a, ok := algorithms[id]
+	if !ok {
+		return fmt.Errorf("algorithm %#x: %w", id, ErrUnknownAlgorithm)
+	}
+
+	defer rollback(dst, dst.Mark(), &err)
+
+	if err := dst.WriteUint8(id); err != nil {
+		return err
+	}
+
+	if err := a.Compress(dst, remaining(src)); err != nil {
+		return err
+	}
+
+	return src.Shift(src.Remaining())
+}
+
+// Reads the id of the algorithm from src, decompresses the bytes left after it and writes them to dst. src
+// is drained on success, while dst is left as it was on failure.
+func (c Compressor) Decompress(dst, src *buffer.Buffer) (err error) {
+	id, err := src.ReadUint8()
+	if err != nil {
+		return err
+	}
+
+	a, ok := algorithms[id]
+	if !ok {
+		return &buffer.DecodeError{Offset: src.Offset() - 1, Size: 1, Remaining: src.Remaining() + 1, Err: ErrUnknownAlgorithm}
+	}
+
+	defer rollback(dst, dst.Mark(), &err)
+
+	offset, n := src.Offset(), src.Remaining()
+	if err := a.Decompress(dst, remaining(src), c.maxDecompressedSize()); err != nil {
+		return &buffer.DecodeError{Offset: offset, Size: n, Remaining: n, Err: err}
+	}
+
+	return src.Shift(n)
+}
+
+// Returns the configured maximum decompressed size or the default one
+func (c Compressor) maxDecompressedSize() int {
+	if c.MaxDecompressedSize > 0 {
+		return c.MaxDecompressedSize
+	}
+
+	return DefaultMaxDecompressedSize
+}
+
+// Returns a shared reference to the bytes left in the buffer without moving its cursor
+func remaining(b *buffer.Buffer) []byte {
+	return b.Slice()[b.Offset():b.Length()]
+}
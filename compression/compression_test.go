@@ -0,0 +1,152 @@
+package compression
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"math/rand/v2"
+	"testing"
+
+	"github.com/gamevidea/binary/bedrock"
+	"github.com/gamevidea/binary/buffer"
+)
+
+func TestRoundTrip(t *testing.T) {
+	payload := bytes.Repeat([]byte("compressed payload "), 64)
+
+	for _, id := range []uint8{IDFlate, IDSnappy, IDNone} {
+		c := Compressor{ID: id}
+
+		compressed := buffer.NewGrowable(0)
+		if err := c.Compress(compressed, buffer.From(payload)); err != nil {
+			t.Fatalf("Compress() with algorithm %#x error = %v", id, err)
+		}
+		compressed.Resize(compressed.Offset())
+		compressed.SetOffset(0)
+
+		decompressed := buffer.NewGrowable(0)
+		if err := c.Decompress(decompressed, compressed); err != nil {
+			t.Fatalf("Decompress() with algorithm %#x error = %v", id, err)
+		}
+
+		if !bytes.Equal(decompressed.Bytes(), payload) {
+			t.Fatalf("Decompress() with algorithm %#x did not return the original payload", id)
+		}
+
+		// Nothing but the payload is part of the buffer's contents, such as room made for reading into it.
+		if decompressed.Length() != decompressed.Offset() {
+			t.Fatalf("Decompress() with algorithm %#x left a length of %d at offset %d", id, decompressed.Length(), decompressed.Offset())
+		}
+	}
+}
+
+func TestBatchRoundTrip(t *testing.T) {
+	packets := [][]byte{[]byte("first packet"), bytes.Repeat([]byte("second packet "), 100)}
+
+	for _, id := range []uint8{IDFlate, IDSnappy, IDNone} {
+		c := Compressor{ID: id}
+
+		batch := buffer.NewGrowable(0)
+		w := &bedrock.BatchWriter{}
+		w.Reset(batch)
+		for _, pk := range packets {
+			if err := w.Write(pk); err != nil {
+				t.Fatal(err)
+			}
+		}
+		batch.Resize(batch.Offset())
+		batch.SetOffset(0)
+
+		compressed := buffer.NewGrowable(0)
+		if err := c.Compress(compressed, batch); err != nil {
+			t.Fatalf("Compress() with algorithm %#x error = %v", id, err)
+		}
+		compressed.Resize(compressed.Offset())
+		compressed.SetOffset(0)
+
+		decompressed := buffer.NewGrowable(0)
+		if err := c.Decompress(decompressed, compressed); err != nil {
+			t.Fatalf("Decompress() with algorithm %#x error = %v", id, err)
+		}
+		decompressed.SetOffset(0)
+
+		r := &bedrock.BatchReader{}
+		r.Reset(decompressed)
+		for i, want := range packets {
+			pk, err := r.Next()
+			if err != nil {
+				t.Fatalf("Next() of packet %d with algorithm %#x error = %v", i, id, err)
+			}
+
+			if !bytes.Equal(pk.Slice(), want) {
+				t.Fatalf("Next() of packet %d with algorithm %#x did not return the original packet", i, id)
+			}
+		}
+
+		if _, err := r.Next(); err != io.EOF {
+			t.Fatalf("Next() after the last packet with algorithm %#x error = %v, want io.EOF", id, err)
+		}
+	}
+}
+
+func TestDecompressRollback(t *testing.T) {
+	payload := bytes.Repeat([]byte{0xab}, 4096)
+
+	for _, id := range []uint8{IDFlate, IDSnappy, IDNone} {
+		compressed := buffer.NewGrowable(0)
+		if err := (Compressor{ID: id}).Compress(compressed, buffer.From(payload)); err != nil {
+			t.Fatalf("Compress() with algorithm %#x error = %v", id, err)
+		}
+		data := compressed.Bytes()
+
+		tests := []struct {
+			name string
+			src  []byte
+			max  int
+			err  error
+		}{
+			{"too large", data, len(payload) - 1, ErrTooLarge},
+			{"corrupt", append(append([]byte(nil), data[:len(data)/2]...), 0xff, 0xff, 0xff), len(payload), ErrCorrupt},
+		}
+
+		for _, tt := range tests {
+			if id == IDNone && tt.err == ErrCorrupt {
+				continue
+			}
+
+			dst := buffer.NewGrowable(0)
+			dst.Write([]byte("prefix"))
+			dst.Resize(dst.Offset())
+
+			err := (Compressor{MaxDecompressedSize: tt.max}).Decompress(dst, buffer.From(tt.src))
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("Decompress() %s with algorithm %#x error = %v, want %v", tt.name, id, err, tt.err)
+			}
+
+			if dst.Offset() != 6 || dst.Length() != 6 {
+				t.Fatalf("Decompress() %s with algorithm %#x left offset %d and length %d, want 6 and 6", tt.name, id, dst.Offset(), dst.Length())
+			}
+		}
+	}
+}
+
+func TestCompressRollback(t *testing.T) {
+	payload := make([]byte, 256)
+	for i, r := 0, rand.New(rand.NewPCG(1, 2)); i < len(payload); i++ {
+		payload[i] = byte(r.Uint32())
+	}
+
+	for _, id := range []uint8{IDFlate, IDSnappy, IDNone} {
+		dst := buffer.New(32)
+		dst.Write([]byte("prefix"))
+
+		src := buffer.From(payload)
+		if err := (Compressor{ID: id}).Compress(dst, src); !errors.Is(err, buffer.ErrEndOfFile) {
+			t.Fatalf("Compress() into a full buffer with algorithm %#x error = %v, want ErrEndOfFile", id, err)
+		}
+
+		if dst.Offset() != 6 || dst.Length() != 32 || src.Offset() != 0 {
+			t.Fatalf("Compress() with algorithm %#x left offset %d and length %d with %d bytes of src drained", id, dst.Offset(), dst.Length(), src.Offset())
+		}
+	}
+}
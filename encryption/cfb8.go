@@ -0,0 +1,56 @@
+package encryption
+
+import "crypto/cipher"
+
+// cfb8 is the 8-bit cipher feedback mode, which is missing from crypto/cipher. It encrypts a byte at a time
+// by xoring it with the first byte of the encrypted shift register, then shifts the ciphertext byte into the
+// register.
+type cfb8 struct {
+	block   cipher.Block
+	decrypt bool
+
+	// register holds the shift register twice over so that shifting is a matter of moving the window
+	register []byte
+	pos      int
+	out      []byte
+}
+
+// Creates and returns a cfb8 stream of the provided block and iv, which must be as long as the block size
+func newCFB8(block cipher.Block, iv []byte, decrypt bool) *cfb8 {
+	size := block.BlockSize()
+
+	c := &cfb8{
+		block:    block,
+		decrypt:  decrypt,
+		register: make([]byte, size*2),
+		out:      make([]byte, size),
+	}
+	copy(c.register, iv)
+
+	return c
+}
+
+// Encrypts or decrypts src into dst, implementing cipher.Stream
+func (c *cfb8) XORKeyStream(dst, src []byte) {
+	size := len(c.out)
+
+	for i := range src {
+		c.block.Encrypt(c.out, c.register[c.pos:c.pos+size])
+
+		v := src[i]
+		dst[i] = v ^ c.out[0]
+
+		feedback := dst[i]
+		if c.decrypt {
+			feedback = v
+		}
+
+		if c.pos+size == len(c.register) {
+			copy(c.register, c.register[c.pos+1:])
+			c.pos = -1
+		}
+
+		c.register[c.pos+size] = feedback
+		c.pos++
+	}
+}
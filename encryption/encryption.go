@@ -0,0 +1,201 @@
+// Package encryption implements the encryption of bedrock batches, which starts once the server handshake
+// completed during login. The payload of every batch after its id is encrypted with AES-256 as a stream and
+// carries a trailing checksum of its plaintext, so that tampered or reordered batches are detected.
+package encryption
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash"
+
+	"github.com/gamevidea/binary/buffer"
+)
+
+const (
+	// KeySize is the size of the AES-256 key batches are encrypted with
+	KeySize = 32
+	// ChecksumSize is the size of the checksum trailing every encrypted payload
+	ChecksumSize = 8
+)
+
+// Mode is the block cipher mode batches are encrypted with
+type Mode uint8
+
+const (
+	// CTR is the mode of current versions, which the game refers to as GCM although it uses the counter
+	// stream without authentication tags. Its iv is the first 12 bytes of the key followed by a big endian
+	// counter starting at 2.
+	CTR Mode = iota
+	// CFB8 is the mode of legacy versions. Its iv is the first 16 bytes of the key.
+	CFB8
+)
+
+// ErrUnknownMode is the error returned when a cipher is created with a mode that is not supported
+var ErrUnknownMode = errors.New("could not create the cipher as its mode is unknown")
+
+// ChecksumError is the error returned when the checksum of a decrypted payload does not match the one it
+// carries, which means the payload was tampered with, corrupted or received out of order
+type ChecksumError struct {
+	// Counter is the receive counter the checksum was computed with
+	Counter uint64
+	// Expected is the checksum computed from the decrypted payload
+	Expected [ChecksumSize]byte
+	// Actual is the checksum the payload carried
+	Actual [ChecksumSize]byte
+}
+
+func (e *ChecksumError) Error() string {
+	return fmt.Sprintf("could not verify the checksum of payload %d: expected %x, got %x", e.Counter, e.Expected, e.Actual)
+}
+
+// Derives the key batches are encrypted with from the ECDH shared secret and the salt the server sent in
+// its handshake, which is the SHA-256 of the salt followed by the secret
+func DeriveKey(secret, salt []byte) [KeySize]byte {
+	h := sha256.New()
+	h.Write(salt)
+	h.Write(secret)
+
+	var key [KeySize]byte
+	h.Sum(key[:0])
+
+	return key
+}
+
+// Computes the ECDH shared secret of the provided private key and the peer's public key, which are P-384 keys
+// in the game, and derives the key batches are encrypted with from it and the salt
+func SharedKey(private *ecdh.PrivateKey, peer *ecdh.PublicKey, salt []byte) ([KeySize]byte, error) {
+	secret, err := private.ECDH(peer)
+	if err != nil {
+		return [KeySize]byte{}, err
+	}
+
+	return DeriveKey(secret, salt), nil
+}
+
+// direction holds the state of one direction of a connection, as each has its own stream and counter
+type direction struct {
+	stream  cipher.Stream
+	hash    hash.Hash
+	counter uint64
+	sum     [sha256.Size]byte
+}
+
+// Computes the checksum of the provided plaintext payload with the direction's counter
+func (d *direction) checksum(payload []byte, key *[KeySize]byte) [ChecksumSize]byte {
+	var counter [8]byte
+	binary.LittleEndian.PutUint64(counter[:], d.counter)
+
+	d.hash.Reset()
+	d.hash.Write(counter[:])
+	d.hash.Write(payload)
+	d.hash.Write(key[:])
+	d.hash.Sum(d.sum[:0])
+
+	return [ChecksumSize]byte(d.sum[:ChecksumSize])
+}
+
+// Cipher encrypts sent and decrypts received batch payloads in place, tracking the counter of each
+// direction. Encrypt and Decrypt may be called concurrently with each other, but neither may be called
+// concurrently with itself as payloads must be processed in the order they are sent and received.
+type Cipher struct {
+	key  [KeySize]byte
+	send direction
+	recv direction
+}
+
+// Creates and returns a new Cipher of the provided key and mode with both counters at zero
+func New(key [KeySize]byte, mode Mode) (*Cipher, error) {
+	block, err := aes.NewCipher(key[:])
+	if err != nil {
+		return nil, err
+	}
+
+	c := &Cipher{
+		key:  key,
+		send: direction{hash: sha256.New()},
+		recv: direction{hash: sha256.New()},
+	}
+
+	switch mode {
+	case CTR:
+		var iv [aes.BlockSize]byte
+		copy(iv[:], key[:12])
+		iv[aes.BlockSize-1] = 2
+
+		c.send.stream = cipher.NewCTR(block, iv[:])
+		c.recv.stream = cipher.NewCTR(block, iv[:])
+	case CFB8:
+		c.send.stream = newCFB8(block, key[:aes.BlockSize], false)
+		c.recv.stream = newCFB8(block, key[:aes.BlockSize], true)
+	default:
+		return nil, fmt.Errorf("mode %d: %w", mode, ErrUnknownMode)
+	}
+
+	return c, nil
+}
+
+// Returns the number of payloads encrypted so far, which is the counter of the next one
+func (c *Cipher) SendCounter() uint64 {
+	return c.send.counter
+}
+
+// Returns the number of payloads decrypted so far, which is the counter of the next one
+func (c *Cipher) ReceiveCounter() uint64 {
+	return c.recv.counter
+}
+
+// Encrypts the payload written to the buffer between the provided start offset and its cursor in place,
+// which usually starts after the batch id. The checksum is written at the cursor and encrypted along with
+// the payload. Nothing is encrypted and the counter is left untouched if the operation failed.
+func (c *Cipher) Encrypt(b *buffer.Buffer, start int) error {
+	if start < 0 || start > b.Offset() {
+		return fmt.Errorf("start offset %d: %w", start, buffer.ErrInvalidLength)
+	}
+
+	sum := c.send.checksum(b.Slice()[start:b.Offset()], &c.key)
+
+	offset := b.Offset()
+	if _, err := b.Write(sum[:]); err != nil {
+		b.SetOffset(offset)
+		return err
+	}
+
+	payload := b.Slice()[start:b.Offset()]
+	c.send.stream.XORKeyStream(payload, payload)
+	c.send.counter++
+
+	return nil
+}
+
+// Decrypts the bytes left in the buffer in place and verifies their trailing checksum. On success the
+// buffer is resized to exclude the checksum, so that the bytes left are the plaintext payload. The counter
+// is advanced even if the checksum does not match, as the stream was consumed, in which case a
+// *ChecksumError is returned and the connection should be closed.
+func (c *Cipher) Decrypt(b *buffer.Buffer) error {
+	if b.Remaining() < ChecksumSize {
+		return &buffer.DecodeError{Offset: b.Offset(), Size: ChecksumSize, Remaining: b.Remaining(), Err: buffer.ErrEndOfFile}
+	}
+
+	data := b.Slice()[b.Offset():b.Length()]
+	c.recv.stream.XORKeyStream(data, data)
+
+	n := len(data) - ChecksumSize
+	expected := c.recv.checksum(data[:n], &c.key)
+	actual := [ChecksumSize]byte(data[n:])
+
+	counter := c.recv.counter
+	c.recv.counter++
+
+	if subtle.ConstantTimeCompare(expected[:], actual[:]) != 1 {
+		return &ChecksumError{Counter: counter, Expected: expected, Actual: actual}
+	}
+
+	b.Resize(b.Length() - ChecksumSize)
+	return nil
+}
@@ -0,0 +1,185 @@
+package encryption
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"testing"
+
+	"github.com/gamevidea/binary/buffer"
+)
+
+// testKey is the key of the known-answer vectors, which are bytes 0 through 31
+var testKey = func() (key [KeySize]byte) {
+	for i := range key {
+		key[i] = byte(i)
+	}
+	return key
+}()
+
+// testPayload is the plaintext of the known-answer vectors
+var testPayload = []byte("bedrock batch payload")
+
+// Returns the bytes of the provided hex string
+func unhex(t *testing.T, s string) []byte {
+	t.Helper()
+
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return b
+}
+
+// Writes a batch id followed by the test payload and encrypts the payload with the provided cipher
+func encrypt(t *testing.T, c *Cipher) *buffer.Buffer {
+	t.Helper()
+
+	b := buffer.NewGrowable(0)
+	b.WriteUint8(0xfe)
+	b.Write(testPayload)
+
+	if err := c.Encrypt(b, 1); err != nil {
+		t.Fatalf("Encrypt() error = %v", err)
+	}
+
+	b.Resize(b.Offset())
+	b.SetOffset(1)
+
+	return b
+}
+
+func TestEncryptKnownAnswer(t *testing.T) {
+	// The vectors were computed with openssl enc -aes-256-ctr and -aes-256-cfb8 over the payload followed by
+	// the first 8 bytes of the SHA-256 of the counter, payload and key.
+	tests := []struct {
+		mode Mode
+		want []string
+	}{
+		{CTR, []string{
+			"2567b269aa86a93bef20e3e8d9c9080cfabae85594236609cb819fa472",
+			"0b65d6737fcd978fa373ec17cc5f9de9fe44578f3d29899ede1af7c5a4",
+		}},
+		{CFB8, []string{"3857f003d6aaae50d63da8e93652670b098a5e2a344e7696951d788fe9"}},
+	}
+
+	for _, tt := range tests {
+		c, err := New(testKey, tt.mode)
+		if err != nil {
+			t.Fatalf("New() error = %v", err)
+		}
+
+		for i, want := range tt.want {
+			b := encrypt(t, c)
+			if got := b.Slice()[:b.Length()]; got[0] != 0xfe || !bytes.Equal(got[1:], unhex(t, want)) {
+				t.Fatalf("Encrypt() of payload %d in mode %d = %x, want fe%s", i, tt.mode, got, want)
+			}
+		}
+
+		if c.SendCounter() != uint64(len(tt.want)) {
+			t.Fatalf("SendCounter() = %d, want %d", c.SendCounter(), len(tt.want))
+		}
+	}
+}
+
+func TestCFB8KnownAnswer(t *testing.T) {
+	// CFB8-AES256.Encrypt of NIST SP 800-38A, F.3.17, which is long enough to wrap the shift register
+	key := unhex(t, "603deb1015ca71be2b73aef0857d77811f352c073b6108d72d9810a30914dff4")
+	iv := unhex(t, "000102030405060708090a0b0c0d0e0f")
+	plaintext := unhex(t, "6bc1bee22e409f96e93d7e117393172aae2d")
+	ciphertext := unhex(t, "dc1f1a8520a64db55fcc8ac554844e889700")
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := make([]byte, len(plaintext))
+	enc := newCFB8(block, iv, false)
+	enc.XORKeyStream(got[:5], plaintext[:5])
+	enc.XORKeyStream(got[5:], plaintext[5:])
+
+	if !bytes.Equal(got, ciphertext) {
+		t.Fatalf("XORKeyStream() = %x, want %x", got, ciphertext)
+	}
+
+	newCFB8(block, iv, true).XORKeyStream(got, got)
+	if !bytes.Equal(got, plaintext) {
+		t.Fatalf("XORKeyStream() decrypting = %x, want %x", got, plaintext)
+	}
+}
+
+func TestDecrypt(t *testing.T) {
+	for _, mode := range []Mode{CTR, CFB8} {
+		send, _ := New(testKey, mode)
+		recv, _ := New(testKey, mode)
+
+		for i := 0; i < 3; i++ {
+			b := encrypt(t, send)
+			if err := recv.Decrypt(b); err != nil {
+				t.Fatalf("Decrypt() of payload %d in mode %d error = %v", i, mode, err)
+			}
+
+			if got := b.Slice()[b.Offset():b.Length()]; !bytes.Equal(got, testPayload) {
+				t.Fatalf("Decrypt() of payload %d in mode %d = %q, want %q", i, mode, got, testPayload)
+			}
+		}
+
+		if recv.ReceiveCounter() != 3 {
+			t.Fatalf("ReceiveCounter() = %d, want 3", recv.ReceiveCounter())
+		}
+	}
+}
+
+func TestDecryptTampered(t *testing.T) {
+	for _, mode := range []Mode{CTR, CFB8} {
+		send, _ := New(testKey, mode)
+		recv, _ := New(testKey, mode)
+
+		b := encrypt(t, send)
+		b.Slice()[3] ^= 0x01
+
+		var checksum *ChecksumError
+		if err := recv.Decrypt(b); !errors.As(err, &checksum) {
+			t.Fatalf("Decrypt() of a tampered payload in mode %d error = %v, want *ChecksumError", mode, err)
+		}
+
+		if checksum.Counter != 0 || checksum.Expected == checksum.Actual {
+			t.Fatalf("Decrypt() of a tampered payload in mode %d = %+v", mode, checksum)
+		}
+
+		if recv.ReceiveCounter() != 1 {
+			t.Fatalf("ReceiveCounter() after a checksum mismatch = %d, want 1", recv.ReceiveCounter())
+		}
+	}
+}
+
+func TestDecryptShort(t *testing.T) {
+	c, _ := New(testKey, CTR)
+
+	var decodeErr *buffer.DecodeError
+	if err := c.Decrypt(buffer.From(make([]byte, ChecksumSize-1))); !errors.As(err, &decodeErr) || !errors.Is(err, buffer.ErrEndOfFile) {
+		t.Fatalf("Decrypt() of a payload shorter than the checksum error = %v, want ErrEndOfFile", err)
+	}
+
+	if c.ReceiveCounter() != 0 {
+		t.Fatal("Decrypt() of a payload shorter than the checksum advanced the counter")
+	}
+}
+
+func TestDeriveKey(t *testing.T) {
+	secret, salt := []byte("shared secret"), []byte("salt")
+
+	if got, want := DeriveKey(secret, salt), sha256.Sum256([]byte("saltshared secret")); got != want {
+		t.Fatalf("DeriveKey() = %x, want %x", got, want)
+	}
+}
+
+func TestNewUnknownMode(t *testing.T) {
+	if _, err := New(testKey, CFB8+1); !errors.Is(err, ErrUnknownMode) {
+		t.Fatalf("New() error = %v, want ErrUnknownMode", err)
+	}
+}
@@ -1,3 +1,5 @@
 module github.com/gamevidea/binary
 
 go 1.22.0
+
+require github.com/golang/snappy v1.0.0
@@ -0,0 +1,231 @@
+// Package bintag parses the `bin` struct tags shared by the reflection based codecs of the buffer package and
+// the code generated by binarygen, so that both encode a struct to the very same bytes.
+package bintag
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/gamevidea/binary/byteorder"
+)
+
+// Name is the key of the struct tag that is parsed by this package
+const Name = "bin"
+
+// Kind is the wire datatype a struct field is encoded as
+type Kind string
+
+const (
+	// KindNone means no kind was provided and it must be inferred from the field's type
+	KindNone Kind = ""
+
+	KindBool      Kind = "bool"
+	KindUint8     Kind = "u8"
+	KindInt8      Kind = "i8"
+	KindUint16    Kind = "u16"
+	KindInt16     Kind = "i16"
+	KindUint24    Kind = "u24"
+	KindUint32    Kind = "u32"
+	KindInt32     Kind = "i32"
+	KindUint64    Kind = "u64"
+	KindInt64     Kind = "i64"
+	KindFloat32   Kind = "f32"
+	KindFloat64   Kind = "f64"
+	KindVarInt    Kind = "varint"
+	KindVarInt32  Kind = "varint32"
+	KindVarInt64  Kind = "varint64"
+	KindVarUint   Kind = "varuint"
+	KindVarUint32 Kind = "varuint32"
+	KindVarUint64 Kind = "varuint64"
+	KindString    Kind = "string"
+	KindBytes     Kind = "bytes"
+	KindAddr      Kind = "addr"
+)
+
+// kinds is the set of kinds accepted in a tag
+var kinds = map[Kind]bool{
+	KindBool: true, KindUint8: true, KindInt8: true, KindUint16: true, KindInt16: true, KindUint24: true,
+	KindUint32: true, KindInt32: true, KindUint64: true, KindInt64: true, KindFloat32: true, KindFloat64: true,
+	KindVarInt: true, KindVarInt32: true, KindVarInt64: true, KindVarUint: true, KindVarUint32: true,
+	KindVarUint64: true, KindString: true, KindBytes: true, KindAddr: true,
+}
+
+// Prefix is the datatype used to encode the length of strings, byte slices and slices
+type Prefix string
+
+const (
+	PrefixVarUint32 Prefix = "varuint32"
+	PrefixUint16LE  Prefix = "u16le"
+	PrefixUint16BE  Prefix = "u16be"
+	PrefixInt32LE   Prefix = "i32le"
+)
+
+// ErrInvalidTag is the error returned when a struct tag could not be parsed
+var ErrInvalidTag = errors.New("could not parse the bin struct tag")
+
+// Tag is a parsed `bin` struct tag. Its grammar is a comma separated list of an optional kind followed by
+// options:
+//
+//	le, be        byte order of fixed size numbers, little-endian by default
+//	prefix=P      length prefix of strings, byte slices and slices, varuint32 by default
+//	len=Field     take the element count from a previously decoded integer field instead of a prefix
+//	max=N         maximum length of strings, byte slices and slices
+//	utf8          reject strings that are not valid UTF-8
+//
+// A tag of "-" skips the field. The kind of slice and array fields applies to their elements.
+type Tag struct {
+	Kind   Kind
+	Endian byteorder.Endian
+	Prefix Prefix
+	Len    string
+	Max    int
+	UTF8   bool
+	Skip   bool
+}
+
+// Parses the provided struct tag value and returns it
+func Parse(s string) (Tag, error) {
+	t := Tag{Endian: byteorder.LittleEndian, Prefix: PrefixVarUint32}
+	if s == "-" {
+		t.Skip = true
+		return t, nil
+	}
+
+	if s == "" {
+		return t, nil
+	}
+
+	for i, opt := range strings.Split(s, ",") {
+		key, value, ok := strings.Cut(opt, "=")
+
+		switch {
+		case !ok && i == 0 && kinds[Kind(key)]:
+			t.Kind = Kind(key)
+		case !ok && key == "le":
+			t.Endian = byteorder.LittleEndian
+		case !ok && key == "be":
+			t.Endian = byteorder.BigEndian
+		case !ok && key == "utf8":
+			t.UTF8 = true
+		case ok && key == "prefix":
+			switch p := Prefix(value); p {
+			case PrefixVarUint32, PrefixUint16LE, PrefixUint16BE, PrefixInt32LE:
+				t.Prefix = p
+			default:
+				return Tag{}, fmt.Errorf("%w: unknown prefix %q", ErrInvalidTag, value)
+			}
+		case ok && key == "len":
+			if value == "" {
+				return Tag{}, fmt.Errorf("%w: empty len field", ErrInvalidTag)
+			}
+			t.Len = value
+		case ok && key == "max":
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 0 {
+				return Tag{}, fmt.Errorf("%w: invalid max %q", ErrInvalidTag, value)
+			}
+			t.Max = n
+		default:
+			return Tag{}, fmt.Errorf("%w: unknown option %q", ErrInvalidTag, opt)
+		}
+	}
+
+	return t, nil
+}
+
+// Returns the kind a field of the provided basic type name is encoded as when its tag has no kind, or KindNone
+// if the type has no default encoding.
+func Infer(typ string) Kind {
+	switch typ {
+	case "bool":
+		return KindBool
+	case "uint8", "byte":
+		return KindUint8
+	case "int8":
+		return KindInt8
+	case "uint16":
+		return KindUint16
+	case "int16":
+		return KindInt16
+	case "uint32":
+		return KindUint32
+	case "int32", "rune":
+		return KindInt32
+	case "uint64":
+		return KindUint64
+	case "int64":
+		return KindInt64
+	case "float32":
+		return KindFloat32
+	case "float64":
+		return KindFloat64
+	case "string":
+		return KindString
+	}
+
+	return KindNone
+}
+
+// Resolves the width dependent varint kinds into their 32-bit or 64-bit variants for a field of the provided
+// number of bits.
+func (k Kind) Resolve(bits int) Kind {
+	switch k {
+	case KindVarInt:
+		if bits > 32 {
+			return KindVarInt64
+		}
+		return KindVarInt32
+	case KindVarUint:
+		if bits > 32 {
+			return KindVarUint64
+		}
+		return KindVarUint32
+	}
+
+	return k
+}
+
+// Reports whether the kind is an unsigned integer
+func (k Kind) Unsigned() bool {
+	switch k {
+	case KindUint8, KindUint16, KindUint24, KindUint32, KindUint64, KindVarUint32, KindVarUint64:
+		return true
+	}
+
+	return false
+}
+
+// Reports whether the kind is a signed integer
+func (k Kind) Signed() bool {
+	switch k {
+	case KindInt8, KindInt16, KindInt32, KindInt64, KindVarInt32, KindVarInt64:
+		return true
+	}
+
+	return false
+}
+
+// Reports whether the kind is a floating point number
+func (k Kind) Float() bool {
+	return k == KindFloat32 || k == KindFloat64
+}
+
+// Returns the number of bits of the integer or floating point number the kind is decoded into
+func (k Kind) Bits() int {
+	switch k {
+	case KindUint8, KindInt8:
+		return 8
+	case KindUint16, KindInt16:
+		return 16
+	case KindUint24:
+		return 24
+	case KindUint32, KindInt32, KindFloat32, KindVarInt32, KindVarUint32:
+		return 32
+	case KindUint64, KindInt64, KindFloat64, KindVarInt64, KindVarUint64:
+		return 64
+	}
+
+	return 0
+}
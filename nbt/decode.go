@@ -0,0 +1,381 @@
+package nbt
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/gamevidea/binary/buffer"
+)
+
+// Decoder reads NBT data from a buffer while enforcing limits that guard against malicious payloads
+type Decoder struct {
+	// Encoding is the wire format of the data
+	Encoding Encoding
+	// MaxDepth is the maximum nesting of compounds and lists. Zero means DefaultMaxDepth.
+	MaxDepth int
+	// MaxLength is the maximum number of elements of lists and arrays and bytes of strings. Zero means
+	// DefaultMaxLength.
+	MaxLength int
+}
+
+// Decodes the NBT data in the provided slice using the provided encoding into the value pointed to by v. It
+// is a shorthand for a Decoder with the default limits.
+func Unmarshal(e Encoding, data []byte, v any) error {
+	return Decoder{Encoding: e}.Decode(buffer.From(data), v)
+}
+
+// Decodes a root compound from the buffer into the value pointed to by v. v may point to a struct, a map with
+// string keys or an empty interface, which receives a map[string]any.
+func (d Decoder) Decode(b *buffer.Buffer, v any) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() {
+		return fmt.Errorf("%w: %T", ErrMismatchedType, v)
+	}
+
+	root, err := d.ReadRoot(b)
+	if err != nil {
+		return err
+	}
+
+	return assign(rv.Elem(), root)
+}
+
+// Reads a root compound from the buffer and returns its contents. The name of the root tag is discarded.
+func (d Decoder) ReadRoot(b *buffer.Buffer) (map[string]any, error) {
+	t, err := b.ReadUint8()
+	if err != nil {
+		return nil, err
+	}
+
+	if t != tagCompound {
+		return nil, &buffer.DecodeError{Offset: b.Offset() - 1, Size: 1, Remaining: b.Remaining() + 1, Err: ErrInvalidTag}
+	}
+
+	if _, err := b.ReadString(d.Encoding.layout(d.maxLength())); err != nil {
+		return nil, err
+	}
+
+	v, err := d.readPayload(b, tagCompound, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	return v.(map[string]any), nil
+}
+
+// Returns the configured maximum depth or the default one
+func (d Decoder) maxDepth() int {
+	if d.MaxDepth > 0 {
+		return d.MaxDepth
+	}
+
+	return DefaultMaxDepth
+}
+
+// Returns the configured maximum length or the default one
+func (d Decoder) maxLength() int {
+	if d.MaxLength > 0 {
+		return d.MaxLength
+	}
+
+	return DefaultMaxLength
+}
+
+// Reads the length of an array or list and validates it against the limits. As every element takes at
+// least one byte, lengths larger than the number of bytes left in the buffer are rejected before allocating.
+func (d Decoder) readLength(b *buffer.Buffer) (int, error) {
+	offset := b.Offset()
+
+	n, err := d.Encoding.readInt32(b)
+	if err != nil {
+		return 0, err
+	}
+
+	if n < 0 || int(n) > d.maxLength() {
+		return 0, &buffer.DecodeError{Offset: offset, Size: b.Offset() - offset, Remaining: b.Remaining() + b.Offset() - offset, Err: buffer.ErrInvalidLength}
+	}
+
+	if int(n) > b.Remaining() {
+		return 0, &buffer.DecodeError{Offset: offset, Size: b.Offset() - offset + int(n), Remaining: b.Remaining() + b.Offset() - offset, Err: buffer.ErrEndOfFile}
+	}
+
+	return int(n), nil
+}
+
+// Reads the payload of a tag of the provided type and returns it as a Go value
+func (d Decoder) readPayload(b *buffer.Buffer, t tagType, depth int) (any, error) {
+	e := d.Encoding
+
+	switch t {
+	case tagByte:
+		return b.ReadUint8()
+	case tagShort:
+		return b.ReadInt16(e.order())
+	case tagInt:
+		return e.readInt32(b)
+	case tagLong:
+		return e.readInt64(b)
+	case tagFloat:
+		return b.ReadFloat32(e.order())
+	case tagDouble:
+		return b.ReadFloat64(e.order())
+	case tagString:
+		return b.ReadString(e.layout(d.maxLength()))
+	case tagByteArray:
+		n, err := d.readLength(b)
+		if err != nil {
+			return nil, err
+		}
+
+		v := make([]byte, n)
+		return v, b.ReadFull(v)
+	case tagIntArray:
+		n, err := d.readLength(b)
+		if err != nil {
+			return nil, err
+		}
+
+		v := make([]int32, n)
+		for i := range v {
+			if v[i], err = e.readInt32(b); err != nil {
+				return nil, buffer.WithField(err, fmt.Sprintf("[%d]", i))
+			}
+		}
+
+		return v, nil
+	case tagLongArray:
+		n, err := d.readLength(b)
+		if err != nil {
+			return nil, err
+		}
+
+		v := make([]int64, n)
+		for i := range v {
+			if v[i], err = e.readInt64(b); err != nil {
+				return nil, buffer.WithField(err, fmt.Sprintf("[%d]", i))
+			}
+		}
+
+		return v, nil
+	case tagList:
+		if depth >= d.maxDepth() {
+			return nil, &buffer.DecodeError{Offset: b.Offset(), Remaining: b.Remaining(), Err: ErrMaxDepth}
+		}
+
+		elem, err := b.ReadUint8()
+		if err != nil {
+			return nil, err
+		}
+
+		if elem > tagLongArray {
+			return nil, &buffer.DecodeError{Offset: b.Offset() - 1, Size: 1, Remaining: b.Remaining() + 1, Err: ErrInvalidTag}
+		}
+
+		n, err := d.readLength(b)
+		if err != nil {
+			return nil, err
+		}
+
+		if elem == tagEnd && n > 0 {
+			return nil, &buffer.DecodeError{Offset: b.Offset(), Remaining: b.Remaining(), Err: ErrInvalidTag}
+		}
+
+		v := make([]any, n)
+		for i := range v {
+			if v[i], err = d.readPayload(b, elem, depth+1); err != nil {
+				return nil, buffer.WithField(err, fmt.Sprintf("[%d]", i))
+			}
+		}
+
+		return v, nil
+	case tagCompound:
+		if depth >= d.maxDepth() {
+			return nil, &buffer.DecodeError{Offset: b.Offset(), Remaining: b.Remaining(), Err: ErrMaxDepth}
+		}
+
+		v := make(map[string]any)
+		for {
+			t, err := b.ReadUint8()
+			if err != nil {
+				return nil, err
+			}
+
+			if t == tagEnd {
+				return v, nil
+			}
+
+			if t > tagLongArray {
+				return nil, &buffer.DecodeError{Offset: b.Offset() - 1, Size: 1, Remaining: b.Remaining() + 1, Err: ErrInvalidTag}
+			}
+
+			name, err := b.ReadString(e.layout(d.maxLength()))
+			if err != nil {
+				return nil, err
+			}
+
+			if v[name], err = d.readPayload(b, t, depth+1); err != nil {
+				return nil, buffer.WithField(err, name)
+			}
+		}
+	}
+
+	return nil, &buffer.DecodeError{Offset: b.Offset(), Remaining: b.Remaining(), Err: ErrInvalidTag}
+}
+
+// Stores a decoded tag value into the provided Go value, converting numbers, slices, maps and structs
+func assign(dst reflect.Value, src any) error {
+	mismatch := func() error {
+		return fmt.Errorf("%w: %T into %s", ErrMismatchedType, src, dst.Type())
+	}
+
+	switch dst.Kind() {
+	case reflect.Interface:
+		if dst.NumMethod() != 0 {
+			return mismatch()
+		}
+
+		dst.Set(reflect.ValueOf(src))
+		return nil
+	case reflect.Pointer:
+		if dst.IsNil() {
+			dst.Set(reflect.New(dst.Type().Elem()))
+		}
+
+		return assign(dst.Elem(), src)
+	case reflect.Bool:
+		x, ok := src.(uint8)
+		if !ok {
+			return mismatch()
+		}
+
+		dst.SetBool(x != 0)
+		return nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		x, ok := signed(src)
+		if !ok || dst.OverflowInt(x) {
+			return mismatch()
+		}
+
+		dst.SetInt(x)
+		return nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		x, ok := unsigned(src)
+		if !ok || dst.OverflowUint(x) {
+			return mismatch()
+		}
+
+		dst.SetUint(x)
+		return nil
+	case reflect.Float32, reflect.Float64:
+		switch x := src.(type) {
+		case float32:
+			dst.SetFloat(float64(x))
+		case float64:
+			dst.SetFloat(x)
+		default:
+			return mismatch()
+		}
+
+		return nil
+	case reflect.String:
+		x, ok := src.(string)
+		if !ok {
+			return mismatch()
+		}
+
+		dst.SetString(x)
+		return nil
+	case reflect.Slice, reflect.Array:
+		x := reflect.ValueOf(src)
+		if x.Kind() != reflect.Slice {
+			return mismatch()
+		}
+
+		if dst.Kind() == reflect.Slice {
+			dst.Set(reflect.MakeSlice(dst.Type(), x.Len(), x.Len()))
+		} else if dst.Len() != x.Len() {
+			return mismatch()
+		}
+
+		for i := 0; i < x.Len(); i++ {
+			if err := assign(dst.Index(i), x.Index(i).Interface()); err != nil {
+				return fmt.Errorf("[%d]: %w", i, err)
+			}
+		}
+
+		return nil
+	case reflect.Map:
+		x, ok := src.(map[string]any)
+		if !ok || dst.Type().Key().Kind() != reflect.String {
+			return mismatch()
+		}
+
+		if dst.IsNil() {
+			dst.Set(reflect.MakeMapWithSize(dst.Type(), len(x)))
+		}
+
+		for k, v := range x {
+			elem := reflect.New(dst.Type().Elem()).Elem()
+			if err := assign(elem, v); err != nil {
+				return fmt.Errorf("%s: %w", k, err)
+			}
+
+			dst.SetMapIndex(reflect.ValueOf(k).Convert(dst.Type().Key()), elem)
+		}
+
+		return nil
+	case reflect.Struct:
+		x, ok := src.(map[string]any)
+		if !ok {
+			return mismatch()
+		}
+
+		for _, f := range fieldsOf(dst.Type()) {
+			v, ok := x[f.name]
+			if !ok {
+				continue
+			}
+
+			if err := assign(dst.Field(f.index), v); err != nil {
+				return fmt.Errorf("%s: %w", f.name, err)
+			}
+		}
+
+		return nil
+	}
+
+	return mismatch()
+}
+
+// Returns the value of an integer tag payload. Byte tags are signed in NBT, but are decoded as uint8 to
+// match their common use as flags, so they are reinterpreted here.
+func signed(v any) (int64, bool) {
+	switch x := v.(type) {
+	case uint8:
+		return int64(int8(x)), true
+	case int16:
+		return int64(x), true
+	case int32:
+		return int64(x), true
+	case int64:
+		return x, true
+	}
+
+	return 0, false
+}
+
+// Returns the bits of an integer tag payload as an unsigned integer of the same width
+func unsigned(v any) (uint64, bool) {
+	switch x := v.(type) {
+	case uint8:
+		return uint64(x), true
+	case int16:
+		return uint64(uint16(x)), true
+	case int32:
+		return uint64(uint32(x)), true
+	case int64:
+		return uint64(x), true
+	}
+
+	return 0, false
+}
@@ -0,0 +1,211 @@
+package nbt
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/gamevidea/binary/buffer"
+)
+
+// helloWorld is the hello_world.nbt test file of the NBT specification, encoded big-endian
+var helloWorld = []byte{
+	0x0a, 0x00, 0x0b, 'h', 'e', 'l', 'l', 'o', ' ', 'w', 'o', 'r', 'l', 'd',
+	0x08, 0x00, 0x04, 'n', 'a', 'm', 'e', 0x00, 0x09, 'B', 'a', 'n', 'a', 'n', 'r', 'a', 'm', 'a',
+	0x00,
+}
+
+func TestDecodeHelloWorld(t *testing.T) {
+	var v map[string]any
+	if err := Unmarshal(BigEndian, helloWorld, &v); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+
+	if want := map[string]any{"name": "Bananrama"}; !reflect.DeepEqual(v, want) {
+		t.Fatalf("Unmarshal() = %v, want %v", v, want)
+	}
+
+	data, err := Marshal(BigEndian, v)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The root name is discarded while decoding and written empty while encoding.
+	if want := append([]byte{0x0a, 0x00, 0x00}, helloWorld[14:]...); !reflect.DeepEqual(data, want) {
+		t.Fatalf("Marshal() = %x, want %x", data, want)
+	}
+}
+
+func TestDecodeRoundTrip(t *testing.T) {
+	// Every tag type, holding the Go types the decoder returns for them.
+	v := map[string]any{
+		"byte":      uint8(0xfe),
+		"short":     int16(-12345),
+		"int":       int32(-123456789),
+		"long":      int64(-1234567890123456789),
+		"float":     float32(1.5),
+		"double":    -2.25,
+		"string":    "héllo",
+		"bytes":     []byte{1, 2, 3},
+		"ints":      []int32{-1, 0, 1 << 30},
+		"longs":     []int64{-1, 1 << 62},
+		"list":      []any{"a", "b"},
+		"compounds": []any{map[string]any{"x": int32(1)}, map[string]any{}},
+		"empty":     []any{},
+		"compound":  map[string]any{"nested": map[string]any{"y": int64(2)}},
+	}
+
+	for _, e := range []Encoding{BigEndian, LittleEndian, NetworkLittleEndian} {
+		data, err := Marshal(e, v)
+		if err != nil {
+			t.Fatalf("Marshal(%d) error = %v", e, err)
+		}
+
+		var got map[string]any
+		if err := Unmarshal(e, data, &got); err != nil {
+			t.Fatalf("Unmarshal(%d) error = %v", e, err)
+		}
+
+		if !reflect.DeepEqual(got, v) {
+			t.Fatalf("Unmarshal(%d) = %v, want %v", e, got, v)
+		}
+	}
+}
+
+func TestDecodeStruct(t *testing.T) {
+	type item struct {
+		Name  string `nbt:"Name"`
+		Count int8   `nbt:"Count"`
+	}
+
+	type entity struct {
+		ID        string  `nbt:"id"`
+		OnGround  bool    `nbt:"OnGround"`
+		Health    float32 `nbt:"Health"`
+		Age       int     `nbt:"Age"`
+		Flags     uint32
+		Pos       [3]float64 `nbt:"Pos"`
+		Inventory []item     `nbt:"Inventory"`
+		Owner     *item      `nbt:"Owner"`
+		Skipped   string     `nbt:"-"`
+	}
+
+	want := entity{
+		ID:        "minecraft:pig",
+		OnGround:  true,
+		Health:    10,
+		Age:       -1,
+		Flags:     0xffffffff,
+		Pos:       [3]float64{1.5, 64, -3},
+		Inventory: []item{{Name: "minecraft:carrot", Count: 3}, {Name: "minecraft:saddle", Count: 1}},
+		Owner:     &item{Name: "steve"},
+	}
+
+	for _, e := range []Encoding{BigEndian, LittleEndian, NetworkLittleEndian} {
+		data, err := Marshal(e, want)
+		if err != nil {
+			t.Fatalf("Marshal(%d) error = %v", e, err)
+		}
+
+		got := entity{Skipped: "kept"}
+		if err := Unmarshal(e, data, &got); err != nil {
+			t.Fatalf("Unmarshal(%d) error = %v", e, err)
+		}
+
+		if got.Skipped != "kept" {
+			t.Fatalf("Unmarshal(%d) overwrote a skipped field", e)
+		}
+
+		got.Skipped = ""
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("Unmarshal(%d) = %+v, want %+v", e, got, want)
+		}
+	}
+}
+
+func TestDecodeMismatchedType(t *testing.T) {
+	var v struct {
+		Name int32 `nbt:"name"`
+	}
+
+	if err := Unmarshal(BigEndian, helloWorld, &v); !errors.Is(err, ErrMismatchedType) {
+		t.Fatalf("Unmarshal() error = %v, want ErrMismatchedType", err)
+	}
+}
+
+func TestDecodeMaxDepth(t *testing.T) {
+	const max = 8
+
+	data, err := Marshal(BigEndian, nested(max))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var v map[string]any
+	if err := (Decoder{Encoding: BigEndian, MaxDepth: max}).Decode(buffer.From(data), &v); !errors.Is(err, ErrMaxDepth) {
+		t.Fatalf("Decode() of %d nested compounds error = %v, want ErrMaxDepth", max, err)
+	}
+
+	// Lists count towards the depth as well.
+	var l any = []any{}
+	for i := 0; i < max; i++ {
+		l = []any{l}
+	}
+
+	if data, err = Marshal(BigEndian, map[string]any{"l": l}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := (Decoder{Encoding: BigEndian, MaxDepth: max}).Decode(buffer.From(data), &v); !errors.Is(err, ErrMaxDepth) {
+		t.Fatalf("Decode() of %d nested lists error = %v, want ErrMaxDepth", max, err)
+	}
+}
+
+func TestDecodeMaxLength(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+		max  int
+		want error
+	}{
+		{
+			name: "list beyond the default limit",
+			data: []byte{0x0a, 0x00, 0x00, 0x09, 0x00, 0x01, 'l', 0x01, 0x7f, 0xff, 0xff, 0xff},
+			want: buffer.ErrInvalidLength,
+		},
+		{
+			name: "list beyond the bytes left",
+			data: []byte{0x0a, 0x00, 0x00, 0x09, 0x00, 0x01, 'l', 0x01, 0x00, 0x0f, 0xff, 0xff, 0x00},
+			want: buffer.ErrEndOfFile,
+		},
+		{
+			name: "negative array length",
+			data: []byte{0x0a, 0x00, 0x00, 0x07, 0x00, 0x01, 'a', 0xff, 0xff, 0xff, 0xff},
+			want: buffer.ErrInvalidLength,
+		},
+		{
+			name: "array beyond the configured limit",
+			data: []byte{0x0a, 0x00, 0x00, 0x07, 0x00, 0x01, 'a', 0x00, 0x00, 0x00, 0x05, 1, 2, 3, 4, 5, 0x00},
+			max:  4,
+			want: buffer.ErrInvalidLength,
+		},
+		{
+			name: "string beyond the configured limit",
+			data: []byte{0x0a, 0x00, 0x00, 0x08, 0x00, 0x01, 's', 0x00, 0x05, 'h', 'e', 'l', 'l', 'o', 0x00},
+			max:  4,
+			want: buffer.ErrInvalidLength,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var v map[string]any
+			err := (Decoder{Encoding: BigEndian, MaxLength: tt.max}).Decode(buffer.From(tt.data), &v)
+
+			var decodeErr *buffer.DecodeError
+			if !errors.Is(err, tt.want) || !errors.As(err, &decodeErr) {
+				t.Fatalf("Decode() error = %v, want a *DecodeError wrapping %v", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,353 @@
+package nbt
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/gamevidea/binary/buffer"
+)
+
+// Encoder writes NBT data to a buffer
+type Encoder struct {
+	// Encoding is the wire format of the data
+	Encoding Encoding
+	// MaxDepth is the maximum nesting of compounds and lists, which also stops self-referential maps and
+	// slices from recursing forever. Zero means DefaultMaxDepth.
+	MaxDepth int
+}
+
+// Encodes the provided struct or map with string keys as a root compound using the provided encoding and
+// returns the resulting bytes.
+func Marshal(e Encoding, v any) ([]byte, error) {
+	b := buffer.NewGrowable(256)
+	if err := (Encoder{Encoding: e}).Encode(b, v); err != nil {
+		return nil, err
+	}
+
+	return b.Bytes(), nil
+}
+
+// Encodes the provided struct or map with string keys as a root compound with an empty name into the buffer.
+// Struct fields are written in declaration order and map entries in the order of their sorted keys.
+func (enc Encoder) Encode(b *buffer.Buffer, v any) error {
+	rv := indirect(reflect.ValueOf(v))
+
+	t, err := tagOf(rv)
+	if err != nil {
+		return err
+	}
+
+	if t != tagCompound {
+		return fmt.Errorf("%w: root must be a compound, got %s", ErrUnsupportedType, rv.Type())
+	}
+
+	if err := b.WriteUint8(tagCompound); err != nil {
+		return err
+	}
+
+	if err := b.WriteString("", enc.Encoding.layout(0)); err != nil {
+		return err
+	}
+
+	return enc.writePayload(b, rv, tagCompound, 0)
+}
+
+// Returns the configured maximum depth or the default one
+func (enc Encoder) maxDepth() int {
+	if enc.MaxDepth > 0 {
+		return enc.MaxDepth
+	}
+
+	return DefaultMaxDepth
+}
+
+// Follows pointers and interfaces to the value they hold
+func indirect(v reflect.Value) reflect.Value {
+	for (v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface) && !v.IsNil() {
+		v = v.Elem()
+	}
+
+	return v
+}
+
+// Returns the tag type the provided Go value is encoded as
+func tagOf(v reflect.Value) (tagType, error) {
+	switch v.Kind() {
+	case reflect.Bool, reflect.Uint8, reflect.Int8:
+		return tagByte, nil
+	case reflect.Int16, reflect.Uint16:
+		return tagShort, nil
+	case reflect.Int32, reflect.Uint32:
+		return tagInt, nil
+	case reflect.Int64, reflect.Uint64, reflect.Int, reflect.Uint:
+		return tagLong, nil
+	case reflect.Float32:
+		return tagFloat, nil
+	case reflect.Float64:
+		return tagDouble, nil
+	case reflect.String:
+		return tagString, nil
+	case reflect.Slice, reflect.Array:
+		switch v.Type().Elem().Kind() {
+		case reflect.Uint8:
+			return tagByteArray, nil
+		case reflect.Int32:
+			return tagIntArray, nil
+		case reflect.Int64:
+			return tagLongArray, nil
+		}
+
+		return tagList, nil
+	case reflect.Struct:
+		return tagCompound, nil
+	case reflect.Map:
+		if v.Type().Key().Kind() == reflect.String {
+			return tagCompound, nil
+		}
+	}
+
+	if !v.IsValid() {
+		return 0, fmt.Errorf("%w: nil", ErrUnsupportedType)
+	}
+
+	return 0, fmt.Errorf("%w: %s", ErrUnsupportedType, v.Type())
+}
+
+// Writes the payload of the provided Go value as a tag of the provided type at the provided nesting depth
+func (enc Encoder) writePayload(b *buffer.Buffer, v reflect.Value, t tagType, depth int) error {
+	e := enc.Encoding
+
+	switch t {
+	case tagByte:
+		switch {
+		case v.Kind() == reflect.Bool:
+			return b.WriteBool(v.Bool())
+		case v.CanInt():
+			return b.WriteInt8(int8(v.Int()))
+		}
+
+		return b.WriteUint8(uint8(v.Uint()))
+	case tagShort:
+		if v.CanInt() {
+			return b.WriteInt16(int16(v.Int()), e.order())
+		}
+
+		return b.WriteUint16(uint16(v.Uint()), e.order())
+	case tagInt:
+		if v.CanInt() {
+			return e.writeInt32(b, int32(v.Int()))
+		}
+
+		return e.writeInt32(b, int32(v.Uint()))
+	case tagLong:
+		if v.CanInt() {
+			return e.writeInt64(b, v.Int())
+		}
+
+		return e.writeInt64(b, int64(v.Uint()))
+	case tagFloat:
+		return b.WriteFloat32(float32(v.Float()), e.order())
+	case tagDouble:
+		return b.WriteFloat64(v.Float(), e.order())
+	case tagString:
+		return b.WriteString(v.String(), e.layout(0))
+	case tagByteArray:
+		if err := e.writeInt32(b, int32(v.Len())); err != nil {
+			return err
+		}
+
+		for i := 0; i < v.Len(); i++ {
+			if err := b.WriteUint8(uint8(v.Index(i).Uint())); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	case tagIntArray:
+		if err := e.writeInt32(b, int32(v.Len())); err != nil {
+			return err
+		}
+
+		for i := 0; i < v.Len(); i++ {
+			if err := e.writeInt32(b, int32(v.Index(i).Int())); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	case tagLongArray:
+		if err := e.writeInt32(b, int32(v.Len())); err != nil {
+			return err
+		}
+
+		for i := 0; i < v.Len(); i++ {
+			if err := e.writeInt64(b, v.Index(i).Int()); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	case tagList:
+		if depth >= enc.maxDepth() {
+			return ErrMaxDepth
+		}
+
+		return enc.writeList(b, v, depth)
+	case tagCompound:
+		if depth >= enc.maxDepth() {
+			return ErrMaxDepth
+		}
+
+		if v.Kind() == reflect.Map {
+			return enc.writeMap(b, v, depth)
+		}
+
+		return enc.writeStruct(b, v, depth)
+	}
+
+	return fmt.Errorf("%w: %s", ErrUnsupportedType, v.Type())
+}
+
+// Writes a named tag holding the provided Go value as an entry of a compound at the provided depth
+func (enc Encoder) writeTag(b *buffer.Buffer, name string, v reflect.Value, depth int) error {
+	v = indirect(v)
+
+	t, err := tagOf(v)
+	if err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+
+	if err := b.WriteUint8(t); err != nil {
+		return err
+	}
+
+	if err := b.WriteString(name, enc.Encoding.layout(0)); err != nil {
+		return err
+	}
+
+	if err := enc.writePayload(b, v, t, depth+1); err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+
+	return nil
+}
+
+// Writes a list whose element type is taken from its first element. Every element must share that type.
+func (enc Encoder) writeList(b *buffer.Buffer, v reflect.Value, depth int) error {
+	elem := tagEnd
+	if v.Len() > 0 {
+		t, err := tagOf(indirect(v.Index(0)))
+		if err != nil {
+			return err
+		}
+		elem = t
+	}
+
+	if err := b.WriteUint8(elem); err != nil {
+		return err
+	}
+
+	if err := enc.Encoding.writeInt32(b, int32(v.Len())); err != nil {
+		return err
+	}
+
+	for i := 0; i < v.Len(); i++ {
+		x := indirect(v.Index(i))
+
+		t, err := tagOf(x)
+		if err != nil {
+			return fmt.Errorf("[%d]: %w", i, err)
+		}
+
+		if t != elem {
+			return fmt.Errorf("[%d]: %w: list elements must share a single tag type", i, ErrUnsupportedType)
+		}
+
+		if err := enc.writePayload(b, x, t, depth+1); err != nil {
+			return fmt.Errorf("[%d]: %w", i, err)
+		}
+	}
+
+	return nil
+}
+
+// Writes the entries of a map with string keys followed by the end tag, in the order of the sorted keys
+func (enc Encoder) writeMap(b *buffer.Buffer, v reflect.Value, depth int) error {
+	keys := v.MapKeys()
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].String() < keys[j].String()
+	})
+
+	for _, k := range keys {
+		if err := enc.writeTag(b, k.String(), v.MapIndex(k), depth); err != nil {
+			return err
+		}
+	}
+
+	return b.WriteUint8(tagEnd)
+}
+
+// Writes the fields of a struct followed by the end tag
+func (enc Encoder) writeStruct(b *buffer.Buffer, v reflect.Value, depth int) error {
+	for _, f := range fieldsOf(v.Type()) {
+		fv := v.Field(f.index)
+		if f.omitEmpty && fv.IsZero() {
+			continue
+		}
+
+		if (fv.Kind() == reflect.Pointer || fv.Kind() == reflect.Interface) && fv.IsNil() {
+			continue
+		}
+
+		if err := enc.writeTag(b, f.name, fv, depth); err != nil {
+			return err
+		}
+	}
+
+	return b.WriteUint8(tagEnd)
+}
+
+// field is a struct field that is mapped to a named tag
+type field struct {
+	name      string
+	index     int
+	omitEmpty bool
+}
+
+// fields caches the mapped fields of every struct type that has been encoded or decoded
+var fields sync.Map
+
+// Returns the fields of the provided struct type that are mapped to named tags. The name of a tag defaults to
+// the name of its field and may be overridden with `nbt:"name"`, optionally followed by ",omitempty". Fields
+// tagged with `nbt:"-"` are skipped.
+func fieldsOf(t reflect.Type) []field {
+	if f, ok := fields.Load(t); ok {
+		return f.([]field)
+	}
+
+	var list []field
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if !sf.IsExported() {
+			continue
+		}
+
+		tag := sf.Tag.Get("nbt")
+		if tag == "-" {
+			continue
+		}
+
+		name, opts, _ := strings.Cut(tag, ",")
+		if name == "" {
+			name = sf.Name
+		}
+
+		list = append(list, field{name: name, index: i, omitEmpty: opts == "omitempty"})
+	}
+
+	f, _ := fields.LoadOrStore(t, list)
+	return f.([]field)
+}
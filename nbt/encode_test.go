@@ -0,0 +1,63 @@
+package nbt
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/gamevidea/binary/buffer"
+)
+
+// Returns a root compound holding compounds nested n levels deep below it
+func nested(n int) map[string]any {
+	root := map[string]any{}
+	m := root
+	for i := 0; i < n; i++ {
+		child := map[string]any{}
+		m["c"] = child
+		m = child
+	}
+
+	return root
+}
+
+func TestEncodeSelfReferentialMap(t *testing.T) {
+	m := map[string]any{}
+	m["self"] = m
+
+	if _, err := Marshal(NetworkLittleEndian, m); !errors.Is(err, ErrMaxDepth) {
+		t.Fatalf("Marshal() error = %v, want ErrMaxDepth", err)
+	}
+}
+
+func TestEncodeSelfReferentialList(t *testing.T) {
+	l := make([]any, 1)
+	l[0] = l
+
+	if _, err := Marshal(NetworkLittleEndian, map[string]any{"list": l}); !errors.Is(err, ErrMaxDepth) {
+		t.Fatalf("Marshal() error = %v, want ErrMaxDepth", err)
+	}
+}
+
+func TestEncodeMaxDepthMatchesDecoder(t *testing.T) {
+	const max = 8
+
+	data, err := Marshal(NetworkLittleEndian, nested(max-1))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The deepest value the encoder accepts is the deepest value the decoder accepts with the same limit.
+	var v map[string]any
+	if err := (Decoder{Encoding: NetworkLittleEndian, MaxDepth: max}).Decode(buffer.From(data), &v); err != nil {
+		t.Fatalf("Decode() of %d nested compounds error = %v", max-1, err)
+	}
+
+	enc := Encoder{Encoding: NetworkLittleEndian, MaxDepth: max}
+	if err := enc.Encode(buffer.NewGrowable(0), nested(max-1)); err != nil {
+		t.Fatalf("Encode() of %d nested compounds error = %v", max-1, err)
+	}
+
+	if err := enc.Encode(buffer.NewGrowable(0), nested(max)); !errors.Is(err, ErrMaxDepth) {
+		t.Fatalf("Encode() of %d nested compounds error = %v, want ErrMaxDepth", max, err)
+	}
+}
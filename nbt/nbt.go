@@ -0,0 +1,125 @@
+// Package nbt implements the Named Binary Tag format used by minecraft for item user data, block entities,
+// structure files and level.dat.
+//
+// The three encodings in use are supported: big-endian as used by java edition, little-endian as used by bedrock
+// edition on disk and network little-endian as used by bedrock edition on the wire, which encodes integers and
+// lengths as varints. Tags decode to map[string]any and friends or to structs whose fields are tagged with
+// `nbt:"name"`.
+package nbt
+
+import (
+	"errors"
+
+	"github.com/gamevidea/binary/buffer"
+	"github.com/gamevidea/binary/byteorder"
+)
+
+// Encoding is the wire format of NBT data
+type Encoding uint8
+
+const (
+	// BigEndian is the encoding used by java edition
+	BigEndian Encoding = iota
+	// LittleEndian is the encoding used by bedrock edition on disk, such as level.dat and .mcstructure files
+	LittleEndian
+	// NetworkLittleEndian is the encoding used by bedrock edition on the wire. It encodes ints, longs and the
+	// lengths of strings, arrays and lists as varints.
+	NetworkLittleEndian
+)
+
+// tagType is the id that precedes every named tag and every list
+type tagType = uint8
+
+const (
+	tagEnd tagType = iota
+	tagByte
+	tagShort
+	tagInt
+	tagLong
+	tagFloat
+	tagDouble
+	tagByteArray
+	tagString
+	tagList
+	tagCompound
+	tagIntArray
+	tagLongArray
+)
+
+const (
+	// DefaultMaxDepth is the maximum nesting of compounds and lists accepted when no limit is configured
+	DefaultMaxDepth = 512
+	// DefaultMaxLength is the maximum number of elements of lists and arrays and bytes of strings accepted when
+	// no limit is configured
+	DefaultMaxLength = 1 << 20
+)
+
+// ErrInvalidTag is the error returned when an unknown tag type is encountered while decoding
+var ErrInvalidTag = errors.New("could not parse the nbt tag type")
+
+// ErrMaxDepth is the error returned when compounds and lists are nested deeper than the configured limit while
+// decoding or encoding
+var ErrMaxDepth = errors.New("could not process the nbt data as it exceeds the maximum depth")
+
+// ErrUnsupportedType is the error returned when a Go value has no NBT representation
+var ErrUnsupportedType = errors.New("could not encode the value as its type has no nbt representation")
+
+// ErrMismatchedType is the error returned when a tag can not be stored in the Go value it is decoded into
+var ErrMismatchedType = errors.New("could not decode the nbt tag into a value of a different type")
+
+// Returns the byte order of fixed size numbers in the encoding
+func (e Encoding) order() byteorder.Endian {
+	if e == BigEndian {
+		return byteorder.BigEndian
+	}
+
+	return byteorder.LittleEndian
+}
+
+// Returns the layout of strings in the encoding
+func (e Encoding) layout(max int) buffer.Layout {
+	switch e {
+	case BigEndian:
+		return buffer.Layout{Prefix: buffer.PrefixUint16BE, MaxLength: max}
+	case LittleEndian:
+		return buffer.Layout{Prefix: buffer.PrefixUint16LE, MaxLength: max}
+	}
+
+	return buffer.Layout{Prefix: buffer.PrefixVarUint32, MaxLength: max}
+}
+
+// Reads an int tag payload in the encoding
+func (e Encoding) readInt32(b *buffer.Buffer) (int32, error) {
+	if e == NetworkLittleEndian {
+		return b.ReadVarInt32()
+	}
+
+	return b.ReadInt32(e.order())
+}
+
+// Writes an int tag payload in the encoding
+func (e Encoding) writeInt32(b *buffer.Buffer, v int32) error {
+	if e == NetworkLittleEndian {
+		return b.WriteVarInt32(v)
+	}
+
+	return b.WriteInt32(v, e.order())
+}
+
+// Reads a long tag payload in the encoding
+func (e Encoding) readInt64(b *buffer.Buffer) (int64, error) {
+	if e == NetworkLittleEndian {
+		return b.ReadVarInt64()
+	}
+
+	return b.ReadInt64(e.order())
+}
+
+// Writes a long tag payload in the encoding
+func (e Encoding) writeInt64(b *buffer.Buffer, v int64) error {
+	if e == NetworkLittleEndian {
+		return b.WriteVarInt64(v)
+	}
+
+	return b.WriteInt64(v, e.order())
+}
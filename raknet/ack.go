@@ -0,0 +1,187 @@
+package raknet
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/gamevidea/binary/buffer"
+	"github.com/gamevidea/binary/byteorder"
+)
+
+// DefaultMaxAcknowledgements is the maximum number of sequence numbers an acknowledgement may expand to when
+// no limit is configured
+const DefaultMaxAcknowledgements = 8192
+
+const (
+	// maxSequence is the largest sequence number as they are encoded as uint24
+	maxSequence = 0xffffff
+	// maxRecords is the largest number of records in an acknowledgement as their count is encoded as uint16
+	maxRecords = 0xffff
+)
+
+// ErrNotAcknowledgement is the error returned when a datagram is decoded as an acknowledgement but its header
+// flags do not describe one
+var ErrNotAcknowledgement = errors.New("could not parse the datagram as an acknowledgement")
+
+// ErrInvalidRange is the error returned when a record's range ends before it starts
+var ErrInvalidRange = errors.New("could not parse the acknowledgement record as its range is invalid")
+
+// ErrTooManyAcknowledgements is the error returned when the records of an acknowledgement expand to more
+// sequence numbers than allowed, or when there are too many of them to be encoded
+var ErrTooManyAcknowledgements = errors.New("could not process the acknowledgement as it holds too many sequence numbers")
+
+// Acknowledgement is an ACK or NACK datagram reporting the frame sets that were received or lost. On the wire
+// the sequence numbers are stored as a list of records, each holding either a single number or a range.
+type Acknowledgement struct {
+	// NACK reports whether the datagram reports lost frame sets rather than received ones
+	NACK bool
+	// Sequences are the sequence numbers of the frame sets
+	Sequences []uint32
+	// MaxEntries is the maximum number of sequence numbers the records may expand to when decoding. Zero
+	// means DefaultMaxAcknowledgements.
+	MaxEntries int
+}
+
+// Returns the configured maximum number of entries or the default one
+func (a *Acknowledgement) maxEntries() int {
+	if a.MaxEntries > 0 {
+		return a.MaxEntries
+	}
+
+	return DefaultMaxAcknowledgements
+}
+
+// Returns the header flags of the datagram
+func (a *Acknowledgement) flags() uint8 {
+	if a.NACK {
+		return FlagValid | FlagNACK
+	}
+
+	return FlagValid | FlagACK
+}
+
+// Writes the acknowledgement to the buffer and returns an error if the operation was unsuccessful. The
+// sequence numbers are sorted in place and deduplicated, then compressed into the fewest records possible.
+func (a *Acknowledgement) Encode(b *buffer.Buffer) error {
+	slices.Sort(a.Sequences)
+	a.Sequences = slices.Compact(a.Sequences)
+
+	if len(a.Sequences) > 0 && a.Sequences[len(a.Sequences)-1] > maxSequence {
+		return fmt.Errorf("sequence %d: %w", a.Sequences[len(a.Sequences)-1], ErrInvalidRange)
+	}
+
+	records := 0
+	for i := range a.Sequences {
+		if i == 0 || a.Sequences[i] != a.Sequences[i-1]+1 {
+			records++
+		}
+	}
+
+	if records > maxRecords {
+		return ErrTooManyAcknowledgements
+	}
+
+	if err := b.WriteUint8(a.flags()); err != nil {
+		return err
+	}
+
+	if err := b.WriteUint16(uint16(records), byteorder.BigEndian); err != nil {
+		return err
+	}
+
+	for i := 0; i < len(a.Sequences); {
+		start := i
+		for i++; i < len(a.Sequences) && a.Sequences[i] == a.Sequences[i-1]+1; i++ {
+		}
+
+		if err := writeRecord(b, a.Sequences[start], a.Sequences[i-1]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Writes a record holding the provided range of sequence numbers, or a single one if start equals end
+func writeRecord(b *buffer.Buffer, start, end uint32) error {
+	if err := b.WriteBool(start == end); err != nil {
+		return err
+	}
+
+	if err := b.WriteUint24(start, byteorder.LittleEndian); err != nil {
+		return err
+	}
+
+	if start == end {
+		return nil
+	}
+
+	return b.WriteUint24(end, byteorder.LittleEndian)
+}
+
+// Reads the acknowledgement from the buffer and expands its records into the Sequences slice, which is
+// reused across calls. It returns an error if the records expand to more than the maximum number of entries.
+func (a *Acknowledgement) Decode(b *buffer.Buffer) error {
+	flags, err := b.ReadUint8()
+	if err != nil {
+		return err
+	}
+
+	if flags&FlagValid == 0 || flags&(FlagACK|FlagNACK) == 0 || flags&(FlagACK|FlagNACK) == FlagACK|FlagNACK {
+		return &buffer.DecodeError{Offset: b.Offset() - 1, Size: 1, Remaining: b.Remaining() + 1, Err: ErrNotAcknowledgement}
+	}
+
+	a.NACK = flags&FlagNACK != 0
+
+	n, err := b.ReadUint16(byteorder.BigEndian)
+	if err != nil {
+		return buffer.WithField(err, "records")
+	}
+
+	a.Sequences = a.Sequences[:0]
+	for i := 0; i < int(n); i++ {
+		offset := b.Offset()
+
+		start, end, err := readRecord(b)
+		if err != nil {
+			return buffer.WithField(err, fmt.Sprintf("records[%d]", i))
+		}
+
+		if end < start {
+			return &buffer.DecodeError{Offset: offset, Size: b.Offset() - offset, Remaining: b.Remaining() + b.Offset() - offset, Field: fmt.Sprintf("records[%d]", i), Err: ErrInvalidRange}
+		}
+
+		if int(end-start)+1 > a.maxEntries()-len(a.Sequences) {
+			return &buffer.DecodeError{Offset: offset, Size: b.Offset() - offset, Remaining: b.Remaining() + b.Offset() - offset, Field: fmt.Sprintf("records[%d]", i), Err: ErrTooManyAcknowledgements}
+		}
+
+		for seq := start; seq <= end; seq++ {
+			a.Sequences = append(a.Sequences, seq)
+		}
+	}
+
+	return nil
+}
+
+// Reads a record and returns the range of sequence numbers it holds
+func readRecord(b *buffer.Buffer) (start, end uint32, err error) {
+	single, err := b.ReadBool()
+	if err != nil {
+		return 0, 0, err
+	}
+
+	if start, err = b.ReadUint24(byteorder.LittleEndian); err != nil {
+		return 0, 0, err
+	}
+
+	if single {
+		return start, start, nil
+	}
+
+	if end, err = b.ReadUint24(byteorder.LittleEndian); err != nil {
+		return 0, 0, err
+	}
+
+	return start, end, nil
+}
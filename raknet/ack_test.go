@@ -0,0 +1,100 @@
+package raknet
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/gamevidea/binary/buffer"
+	"github.com/gamevidea/binary/byteorder"
+)
+
+// Returns an encoded acknowledgement holding the provided records, each a pair of start and end
+func records(t *testing.T, ranges ...[2]uint32) *buffer.Buffer {
+	t.Helper()
+
+	b := buffer.NewGrowable(0)
+	b.WriteUint8(FlagValid | FlagACK)
+	b.WriteUint16(uint16(len(ranges)), byteorder.BigEndian)
+	for _, r := range ranges {
+		b.WriteBool(false)
+		b.WriteUint24(r[0], byteorder.LittleEndian)
+		b.WriteUint24(r[1], byteorder.LittleEndian)
+	}
+
+	return buffer.From(b.Bytes())
+}
+
+func TestAcknowledgementRoundTrip(t *testing.T) {
+	a := Acknowledgement{NACK: true, Sequences: []uint32{9, 1, 2, 3, 3, 5, 0xffffff, 10}}
+
+	b := buffer.NewGrowable(0)
+	if err := a.Encode(b); err != nil {
+		t.Fatalf("Encode() error = %v", err)
+	}
+
+	// 1-3 and 9-10 are ranges while 5 and 0xffffff are single records.
+	if n := int(b.Bytes()[1])<<8 | int(b.Bytes()[2]); n != 4 {
+		t.Fatalf("Encode() wrote %d records, want 4", n)
+	}
+
+	var got Acknowledgement
+	if err := got.Decode(buffer.From(b.Bytes())); err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+
+	want := []uint32{1, 2, 3, 5, 9, 10, 0xffffff}
+	if !got.NACK || !slices.Equal(got.Sequences, want) {
+		t.Fatalf("Decode() = %v (nack %v), want %v (nack true)", got.Sequences, got.NACK, want)
+	}
+}
+
+func TestAcknowledgementMaxEntries(t *testing.T) {
+	// A single record spanning every sequence number must not expand past the cap.
+	a := Acknowledgement{}
+	if err := a.Decode(records(t, [2]uint32{0, 0xffffff})); !errors.Is(err, ErrTooManyAcknowledgements) {
+		t.Fatalf("Decode() error = %v, want ErrTooManyAcknowledgements", err)
+	}
+
+	if len(a.Sequences) != 0 {
+		t.Fatalf("Decode() expanded %d entries before failing", len(a.Sequences))
+	}
+
+	// The cap applies to the entries of all records together.
+	a = Acknowledgement{MaxEntries: 10}
+	if err := a.Decode(records(t, [2]uint32{0, 5}, [2]uint32{10, 14})); !errors.Is(err, ErrTooManyAcknowledgements) {
+		t.Fatalf("Decode() error = %v, want ErrTooManyAcknowledgements", err)
+	}
+
+	a = Acknowledgement{MaxEntries: 10}
+	if err := a.Decode(records(t, [2]uint32{0, 4}, [2]uint32{10, 14})); err != nil || len(a.Sequences) != 10 {
+		t.Fatalf("Decode() = %d entries, %v, want exactly 10", len(a.Sequences), err)
+	}
+}
+
+func TestAcknowledgementInvalidRange(t *testing.T) {
+	var a Acknowledgement
+
+	err := a.Decode(records(t, [2]uint32{1, 2}, [2]uint32{7, 3}))
+	if !errors.Is(err, ErrInvalidRange) {
+		t.Fatalf("Decode() error = %v, want ErrInvalidRange", err)
+	}
+
+	var d *buffer.DecodeError
+	if !errors.As(err, &d) || d.Field != "records[1]" {
+		t.Fatalf("Decode() error = %v, want it to point at records[1]", err)
+	}
+
+	if err := (&Acknowledgement{Sequences: []uint32{1 << 24}}).Encode(buffer.NewGrowable(0)); !errors.Is(err, ErrInvalidRange) {
+		t.Fatalf("Encode() of a sequence beyond uint24 error = %v, want ErrInvalidRange", err)
+	}
+}
+
+func TestAcknowledgementNotAcknowledgement(t *testing.T) {
+	for _, flags := range []uint8{0, FlagACK, FlagValid, FlagValid | FlagACK | FlagNACK} {
+		var a Acknowledgement
+		if err := a.Decode(buffer.From([]byte{flags, 0, 0})); !errors.Is(err, ErrNotAcknowledgement) {
+			t.Errorf("Decode() of flags %#x error = %v, want ErrNotAcknowledgement", flags, err)
+		}
+	}
+}
@@ -0,0 +1,321 @@
+// Package raknet implements the datagram layer of the RakNet protocol used by minecraft: pocket edition on top
+// of buffer.Buffer.
+package raknet
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/gamevidea/binary/buffer"
+	"github.com/gamevidea/binary/byteorder"
+)
+
+// Flags of the header byte that precedes every datagram
+const (
+	// FlagValid is set on every datagram that belongs to a connection
+	FlagValid uint8 = 0x80
+	// FlagACK is set on datagrams acknowledging received frame sets
+	FlagACK uint8 = 0x40
+	// FlagNACK is set on datagrams reporting lost frame sets
+	FlagNACK uint8 = 0x20
+	// FlagPacketPair is set on frame sets sent as a pair to measure bandwidth
+	FlagPacketPair uint8 = 0x10
+	// FlagContinuousSend is set on frame sets sent while the send queue is not empty
+	FlagContinuousSend uint8 = 0x08
+	// FlagNeedsBAndAS is set on frame sets that carry congestion control values
+	FlagNeedsBAndAS uint8 = 0x04
+)
+
+const (
+	// splitFlag is set in the frame's flags when the frame holds a fragment of a split packet
+	splitFlag uint8 = 0x10
+	// maxFrameContent is the largest content a frame can hold as its length is encoded in bits as an uint16
+	maxFrameContent = 0xffff / 8
+)
+
+// ErrNotFrameSet is the error returned when a datagram is decoded as a frame set but its header flags do not
+// describe one
+var ErrNotFrameSet = errors.New("could not parse the datagram as a frame set")
+
+// ErrInvalidFrame is the error returned when a frame is empty, too large or has inconsistent split info
+var ErrInvalidFrame = errors.New("could not parse the frame as its length or split info is invalid")
+
+// Reliability describes the delivery guarantees of a frame
+type Reliability uint8
+
+const (
+	Unreliable Reliability = iota
+	UnreliableSequenced
+	Reliable
+	ReliableOrdered
+	ReliableSequenced
+	UnreliableWithAckReceipt
+	ReliableWithAckReceipt
+	ReliableOrderedWithAckReceipt
+)
+
+// Reports whether frames of the reliability carry a message index and are resent until acknowledged
+func (r Reliability) Reliable() bool {
+	switch r {
+	case Reliable, ReliableOrdered, ReliableSequenced, ReliableWithAckReceipt, ReliableOrderedWithAckReceipt:
+		return true
+	}
+
+	return false
+}
+
+// Reports whether frames of the reliability carry a sequence index
+func (r Reliability) Sequenced() bool {
+	return r == UnreliableSequenced || r == ReliableSequenced
+}
+
+// Reports whether frames of the reliability carry an order index and channel. Sequenced frames are ordered on
+// their channel as well.
+func (r Reliability) Ordered() bool {
+	switch r {
+	case UnreliableSequenced, ReliableOrdered, ReliableSequenced, ReliableOrderedWithAckReceipt:
+		return true
+	}
+
+	return false
+}
+
+// Frame is a single encapsulated packet inside of a frame set
+type Frame struct {
+	Reliability Reliability
+
+	// MessageIndex is present on reliable frames
+	MessageIndex uint32
+	// SequenceIndex is present on sequenced frames
+	SequenceIndex uint32
+	// OrderIndex and OrderChannel are present on ordered and sequenced frames
+	OrderIndex   uint32
+	OrderChannel uint8
+
+	// Split reports whether the frame holds a fragment of a split packet, described by the split fields
+	Split      bool
+	SplitCount uint32
+	SplitID    uint16
+	SplitIndex uint32
+
+	// Content is the payload of the frame. Decoding sets it to a shared reference to the buffer's
+	// internal slice.
+	Content []byte
+}
+
+// Returns the number of bytes the frame takes when encoded
+func (f *Frame) Size() int {
+	n := 1 + 2 + len(f.Content)
+	if f.Reliability.Reliable() {
+		n += 3
+	}
+	if f.Reliability.Sequenced() {
+		n += 3
+	}
+	if f.Reliability.Ordered() {
+		n += 3 + 1
+	}
+	if f.Split {
+		n += 4 + 2 + 4
+	}
+
+	return n
+}
+
+// Writes the frame to the buffer and returns an error if the operation was unsuccessful
+func (f *Frame) Encode(b *buffer.Buffer) error {
+	if len(f.Content) == 0 || len(f.Content) > maxFrameContent || f.Reliability > ReliableOrderedWithAckReceipt {
+		return ErrInvalidFrame
+	}
+
+	flags := uint8(f.Reliability) << 5
+	if f.Split {
+		flags |= splitFlag
+	}
+
+	if err := b.WriteUint8(flags); err != nil {
+		return err
+	}
+
+	if err := b.WriteUint16(uint16(len(f.Content))<<3, byteorder.BigEndian); err != nil {
+		return err
+	}
+
+	if f.Reliability.Reliable() {
+		if err := b.WriteUint24(f.MessageIndex, byteorder.LittleEndian); err != nil {
+			return err
+		}
+	}
+
+	if f.Reliability.Sequenced() {
+		if err := b.WriteUint24(f.SequenceIndex, byteorder.LittleEndian); err != nil {
+			return err
+		}
+	}
+
+	if f.Reliability.Ordered() {
+		if err := b.WriteUint24(f.OrderIndex, byteorder.LittleEndian); err != nil {
+			return err
+		}
+
+		if err := b.WriteUint8(f.OrderChannel); err != nil {
+			return err
+		}
+	}
+
+	if f.Split {
+		if err := b.WriteUint32(f.SplitCount, byteorder.BigEndian); err != nil {
+			return err
+		}
+
+		if err := b.WriteUint16(f.SplitID, byteorder.BigEndian); err != nil {
+			return err
+		}
+
+		if err := b.WriteUint32(f.SplitIndex, byteorder.BigEndian); err != nil {
+			return err
+		}
+	}
+
+	_, err := b.Write(f.Content)
+	return err
+}
+
+// Reads the frame from the buffer and returns an error if the operation was unsuccessful
+func (f *Frame) Decode(b *buffer.Buffer) error {
+	flags, err := b.ReadUint8()
+	if err != nil {
+		return err
+	}
+
+	f.Reliability = Reliability(flags >> 5)
+	f.Split = flags&splitFlag != 0
+
+	bits, err := b.ReadUint16(byteorder.BigEndian)
+	if err != nil {
+		return buffer.WithField(err, "length")
+	}
+
+	n := (int(bits) + 7) >> 3
+	if n == 0 {
+		return &buffer.DecodeError{Offset: b.Offset() - 2, Size: 2, Remaining: b.Remaining() + 2, Err: ErrInvalidFrame}
+	}
+
+	f.MessageIndex, f.SequenceIndex, f.OrderIndex, f.OrderChannel = 0, 0, 0, 0
+	if f.Reliability.Reliable() {
+		if f.MessageIndex, err = b.ReadUint24(byteorder.LittleEndian); err != nil {
+			return buffer.WithField(err, "message index")
+		}
+	}
+
+	if f.Reliability.Sequenced() {
+		if f.SequenceIndex, err = b.ReadUint24(byteorder.LittleEndian); err != nil {
+			return buffer.WithField(err, "sequence index")
+		}
+	}
+
+	if f.Reliability.Ordered() {
+		if f.OrderIndex, err = b.ReadUint24(byteorder.LittleEndian); err != nil {
+			return buffer.WithField(err, "order index")
+		}
+
+		if f.OrderChannel, err = b.ReadUint8(); err != nil {
+			return buffer.WithField(err, "order channel")
+		}
+	}
+
+	f.SplitCount, f.SplitID, f.SplitIndex = 0, 0, 0
+	if f.Split {
+		offset := b.Offset()
+
+		if f.SplitCount, err = b.ReadUint32(byteorder.BigEndian); err != nil {
+			return buffer.WithField(err, "split count")
+		}
+
+		if f.SplitID, err = b.ReadUint16(byteorder.BigEndian); err != nil {
+			return buffer.WithField(err, "split id")
+		}
+
+		if f.SplitIndex, err = b.ReadUint32(byteorder.BigEndian); err != nil {
+			return buffer.WithField(err, "split index")
+		}
+
+		if f.SplitCount == 0 || f.SplitIndex >= f.SplitCount {
+			return &buffer.DecodeError{Offset: offset, Size: 10, Remaining: b.Remaining() + 10, Err: ErrInvalidFrame}
+		}
+	}
+
+	if b.Remaining() < n {
+		return &buffer.DecodeError{Offset: b.Offset(), Size: n, Remaining: b.Remaining(), Err: buffer.ErrEndOfFile}
+	}
+
+	f.Content, err = b.Get(n)
+	return err
+}
+
+// FrameSet is a datagram carrying one or more frames
+type FrameSet struct {
+	// Flags are the header flags other than FlagValid, such as FlagContinuousSend
+	Flags uint8
+	// Sequence is the datagram's sequence number used for acknowledgements
+	Sequence uint32
+	// Frames are the frames carried by the datagram
+	Frames []Frame
+}
+
+// Returns the number of bytes the frame set takes when encoded
+func (s *FrameSet) Size() int {
+	n := 1 + 3
+	for i := range s.Frames {
+		n += s.Frames[i].Size()
+	}
+
+	return n
+}
+
+// Writes the frame set to the buffer and returns an error if the operation was unsuccessful
+func (s *FrameSet) Encode(b *buffer.Buffer) error {
+	if err := b.WriteUint8(FlagValid | s.Flags&^(FlagACK|FlagNACK)); err != nil {
+		return err
+	}
+
+	if err := b.WriteUint24(s.Sequence, byteorder.LittleEndian); err != nil {
+		return err
+	}
+
+	for i := range s.Frames {
+		if err := s.Frames[i].Encode(b); err != nil {
+			return fmt.Errorf("frame %d: %w", i, err)
+		}
+	}
+
+	return nil
+}
+
+// Reads the frame set from the buffer until its end and returns an error if the operation was unsuccessful.
+// The Frames slice is reused across calls and the content of every frame shares the buffer's internal slice.
+func (s *FrameSet) Decode(b *buffer.Buffer) error {
+	flags, err := b.ReadUint8()
+	if err != nil {
+		return err
+	}
+
+	if flags&FlagValid == 0 || flags&(FlagACK|FlagNACK) != 0 {
+		return &buffer.DecodeError{Offset: b.Offset() - 1, Size: 1, Remaining: b.Remaining() + 1, Err: ErrNotFrameSet}
+	}
+
+	s.Flags = flags &^ FlagValid
+	if s.Sequence, err = b.ReadUint24(byteorder.LittleEndian); err != nil {
+		return buffer.WithField(err, "sequence")
+	}
+
+	s.Frames = s.Frames[:0]
+	for i := 0; b.Remaining() > 0; i++ {
+		s.Frames = append(s.Frames, Frame{})
+		if err := s.Frames[i].Decode(b); err != nil {
+			return buffer.WithField(err, fmt.Sprintf("frames[%d]", i))
+		}
+	}
+
+	return nil
+}
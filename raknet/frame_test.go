@@ -0,0 +1,67 @@
+package raknet
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/gamevidea/binary/buffer"
+)
+
+func TestFrameLengthNearMaximum(t *testing.T) {
+	// A bit length close to the top of the uint16 range must not wrap around when rounded up to bytes.
+	content := bytes.Repeat([]byte{0xaa}, 8192)
+
+	b := buffer.NewGrowable(0)
+	b.WriteUint8(0)
+	b.Write([]byte{0xff, 0xfc})
+	b.Write(content)
+
+	var f Frame
+	if err := f.Decode(buffer.From(b.Bytes())); err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+
+	if len(f.Content) != len(content) {
+		t.Fatalf("Decode() read %d bytes of content, want %d", len(f.Content), len(content))
+	}
+}
+
+func TestFrameRoundTrip(t *testing.T) {
+	for r := Unreliable; r <= ReliableOrderedWithAckReceipt; r++ {
+		want := Frame{
+			Reliability: r, MessageIndex: 1, SequenceIndex: 2, OrderIndex: 3, OrderChannel: 4,
+			Split: true, SplitCount: 3, SplitID: 7, SplitIndex: 2, Content: []byte("payload"),
+		}
+
+		b := buffer.NewGrowable(0)
+		if err := want.Encode(b); err != nil {
+			t.Fatalf("Encode() of %d error = %v", r, err)
+		}
+
+		if b.Offset() != want.Size() {
+			t.Fatalf("Encode() of %d wrote %d bytes, Size() returned %d", r, b.Offset(), want.Size())
+		}
+
+		var got Frame
+		if err := got.Decode(buffer.From(b.Bytes())); err != nil {
+			t.Fatalf("Decode() of %d error = %v", r, err)
+		}
+
+		if !r.Reliable() {
+			want.MessageIndex = 0
+		}
+		if !r.Sequenced() {
+			want.SequenceIndex = 0
+		}
+		if !r.Ordered() {
+			want.OrderIndex, want.OrderChannel = 0, 0
+		}
+
+		if got.Reliability != want.Reliability || got.MessageIndex != want.MessageIndex ||
+			got.SequenceIndex != want.SequenceIndex || got.OrderIndex != want.OrderIndex ||
+			got.OrderChannel != want.OrderChannel || got.SplitCount != want.SplitCount ||
+			got.SplitID != want.SplitID || got.SplitIndex != want.SplitIndex || !bytes.Equal(got.Content, want.Content) {
+			t.Fatalf("Decode() = %+v, want %+v", got, want)
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package raknet
+
+import (
+	"errors"
+
+	"github.com/gamevidea/binary/buffer"
+)
+
+// Message is a raknet message that starts with its id
+type Message interface {
+	// ID returns the id the message starts with
+	ID() uint8
+	// Encode writes the message, including its id, to the buffer
+	Encode(b *buffer.Buffer) error
+	// Decode reads the message, including its id, from the buffer
+	Decode(b *buffer.Buffer) error
+}
+
+// IDs of the offline messages, which are exchanged before a connection is established
+const (
+	IDUnconnectedPing                uint8 = 0x01
+	IDUnconnectedPingOpenConnections uint8 = 0x02
+	IDOpenConnectionRequest1         uint8 = 0x05
+	IDOpenConnectionReply1           uint8 = 0x06
+	IDOpenConnectionRequest2         uint8 = 0x07
+	IDOpenConnectionReply2           uint8 = 0x08
+	IDAlreadyConnected               uint8 = 0x12
+	IDIncompatibleProtocolVersion    uint8 = 0x19
+	IDUnconnectedPong                uint8 = 0x1c
+)
+
+// IDs of the online messages, which are exchanged inside of frame sets once the offline handshake is done
+const (
+	IDConnectedPing             uint8 = 0x00
+	IDConnectedPong             uint8 = 0x03
+	IDConnectionRequest         uint8 = 0x09
+	IDConnectionRequestAccepted uint8 = 0x10
+	IDNewIncomingConnection     uint8 = 0x13
+	IDDisconnectNotification    uint8 = 0x15
+)
+
+// ErrInvalidID is the error returned when a message is decoded from a buffer holding a message of another id
+var ErrInvalidID = errors.New("could not parse the message as its id does not match")
+
+// Reads the id of a message from the buffer and returns an error if it is not the provided one
+func readID(b *buffer.Buffer, id uint8) error {
+	v, err := b.ReadUint8()
+	if err != nil {
+		return err
+	}
+
+	if v != id {
+		return &buffer.DecodeError{Offset: b.Offset() - 1, Size: 1, Remaining: b.Remaining() + 1, Err: ErrInvalidID}
+	}
+
+	return nil
+}
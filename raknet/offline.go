@@ -0,0 +1,560 @@
+package raknet
+
+import (
+	"errors"
+	"fmt"
+	"net"
+
+	"github.com/gamevidea/binary/buffer"
+	"github.com/gamevidea/binary/byteorder"
+)
+
+const (
+	// mtuOverhead is the size of the ip and udp headers, which are part of the mtu but not of the datagram
+	mtuOverhead = 20 + 8
+	// challengeSize is the size of the challenge a client answers a server's cookie with
+	challengeSize = 64
+)
+
+// ErrInvalidMTU is the error returned when an mtu is too small to hold the message padded to it
+var ErrInvalidMTU = errors.New("could not process the message as its mtu is invalid")
+
+// zeros is the padding written by OpenConnectionRequest1 in chunks
+var zeros [256]byte
+
+// UnconnectedPing is sent by clients to query the status of a server before connecting
+type UnconnectedPing struct {
+	// OpenConnections reports whether only servers with open connections should reply
+	OpenConnections bool
+	// Time is the client's timestamp echoed back by the pong
+	Time       int64
+	ClientGUID int64
+}
+
+// Returns the id of the message, which depends on whether only servers with open connections should reply
+func (m *UnconnectedPing) ID() uint8 {
+	if m.OpenConnections {
+		return IDUnconnectedPingOpenConnections
+	}
+
+	return IDUnconnectedPing
+}
+
+// Writes the message to the buffer and returns an error if the operation was unsuccessful
+func (m *UnconnectedPing) Encode(b *buffer.Buffer) error {
+	if err := b.WriteUint8(m.ID()); err != nil {
+		return err
+	}
+
+	if err := b.WriteInt64(m.Time, byteorder.BigEndian); err != nil {
+		return err
+	}
+
+	if err := b.WriteMagic(); err != nil {
+		return err
+	}
+
+	return b.WriteInt64(m.ClientGUID, byteorder.BigEndian)
+}
+
+// Reads the message from the buffer and returns an error if the operation was unsuccessful. Both ping ids
+// are accepted.
+func (m *UnconnectedPing) Decode(b *buffer.Buffer) (err error) {
+	id, err := b.ReadUint8()
+	if err != nil {
+		return err
+	}
+
+	if id != IDUnconnectedPing && id != IDUnconnectedPingOpenConnections {
+		return &buffer.DecodeError{Offset: b.Offset() - 1, Size: 1, Remaining: b.Remaining() + 1, Err: ErrInvalidID}
+	}
+
+	m.OpenConnections = id == IDUnconnectedPingOpenConnections
+
+	if m.Time, err = b.ReadInt64(byteorder.BigEndian); err != nil {
+		return buffer.WithField(err, "time")
+	}
+
+	if err := b.ReadMagic(); err != nil {
+		return err
+	}
+
+	if m.ClientGUID, err = b.ReadInt64(byteorder.BigEndian); err != nil {
+		return buffer.WithField(err, "client guid")
+	}
+
+	return nil
+}
+
+// UnconnectedPong is sent by servers in reply to an unconnected ping
+type UnconnectedPong struct {
+	// Time is the timestamp of the ping being replied to
+	Time       int64
+	ServerGUID int64
+	// Data describes the server, such as the MCPE;motd;protocol;... string of bedrock servers
+	Data string
+}
+
+// Returns the id of the message
+func (m *UnconnectedPong) ID() uint8 {
+	return IDUnconnectedPong
+}
+
+// Writes the message to the buffer and returns an error if the operation was unsuccessful
+func (m *UnconnectedPong) Encode(b *buffer.Buffer) error {
+	if err := b.WriteUint8(IDUnconnectedPong); err != nil {
+		return err
+	}
+
+	if err := b.WriteInt64(m.Time, byteorder.BigEndian); err != nil {
+		return err
+	}
+
+	if err := b.WriteInt64(m.ServerGUID, byteorder.BigEndian); err != nil {
+		return err
+	}
+
+	if err := b.WriteMagic(); err != nil {
+		return err
+	}
+
+	return b.WriteString(m.Data, buffer.RakNetLayout)
+}
+
+// Reads the message from the buffer and returns an error if the operation was unsuccessful
+func (m *UnconnectedPong) Decode(b *buffer.Buffer) (err error) {
+	if err := readID(b, IDUnconnectedPong); err != nil {
+		return err
+	}
+
+	if m.Time, err = b.ReadInt64(byteorder.BigEndian); err != nil {
+		return buffer.WithField(err, "time")
+	}
+
+	if m.ServerGUID, err = b.ReadInt64(byteorder.BigEndian); err != nil {
+		return buffer.WithField(err, "server guid")
+	}
+
+	if err := b.ReadMagic(); err != nil {
+		return err
+	}
+
+	if m.Data, err = b.ReadString(buffer.RakNetLayout); err != nil {
+		return buffer.WithField(err, "data")
+	}
+
+	return nil
+}
+
+// OpenConnectionRequest1 is the first message of the handshake. It is padded with zeros so that the datagram
+// fills the mtu the client is probing for.
+type OpenConnectionRequest1 struct {
+	Protocol uint8
+	// MTU is the mtu being probed, including the ip and udp headers
+	MTU uint16
+}
+
+// Returns the id of the message
+func (m *OpenConnectionRequest1) ID() uint8 {
+	return IDOpenConnectionRequest1
+}
+
+// Writes the message to the buffer followed by the padding and returns an error if the operation was
+// unsuccessful. It expects the message to be the only one in the datagram.
+func (m *OpenConnectionRequest1) Encode(b *buffer.Buffer) error {
+	n := int(m.MTU) - mtuOverhead - (1 + 16 + 1)
+	if n < 0 {
+		return fmt.Errorf("mtu %d: %w", m.MTU, ErrInvalidMTU)
+	}
+
+	if err := b.WriteUint8(IDOpenConnectionRequest1); err != nil {
+		return err
+	}
+
+	if err := b.WriteMagic(); err != nil {
+		return err
+	}
+
+	if err := b.WriteUint8(m.Protocol); err != nil {
+		return err
+	}
+
+	for n > 0 {
+		l := min(n, len(zeros))
+		if _, err := b.Write(zeros[:l]); err != nil {
+			return err
+		}
+
+		n -= l
+	}
+
+	return nil
+}
+
+// Reads the message from the buffer, consuming the padding until the buffer's end, and returns an error if
+// the operation was unsuccessful. The mtu is derived from the size of the message.
+func (m *OpenConnectionRequest1) Decode(b *buffer.Buffer) (err error) {
+	start := b.Offset()
+
+	if err := readID(b, IDOpenConnectionRequest1); err != nil {
+		return err
+	}
+
+	if err := b.ReadMagic(); err != nil {
+		return err
+	}
+
+	if m.Protocol, err = b.ReadUint8(); err != nil {
+		return buffer.WithField(err, "protocol")
+	}
+
+	mtu := b.Length() - start + mtuOverhead
+	if mtu > 0xffff {
+		return &buffer.DecodeError{Offset: b.Offset(), Size: b.Remaining(), Remaining: b.Remaining(), Field: "padding", Err: ErrInvalidMTU}
+	}
+
+	m.MTU = uint16(mtu)
+	return b.Shift(b.Remaining())
+}
+
+// OpenConnectionReply1 is sent by servers in reply to an OpenConnectionRequest1 that arrived, which means its
+// mtu fits the path.
+type OpenConnectionReply1 struct {
+	ServerGUID int64
+	// Security reports whether the server requires a cookie in the OpenConnectionRequest2
+	Security bool
+	// Cookie is present if Security is set
+	Cookie uint32
+	MTU    uint16
+}
+
+// Returns the id of the message
+func (m *OpenConnectionReply1) ID() uint8 {
+	return IDOpenConnectionReply1
+}
+
+// Writes the message to the buffer and returns an error if the operation was unsuccessful
+func (m *OpenConnectionReply1) Encode(b *buffer.Buffer) error {
+	if err := b.WriteUint8(IDOpenConnectionReply1); err != nil {
+		return err
+	}
+
+	if err := b.WriteMagic(); err != nil {
+		return err
+	}
+
+	if err := b.WriteInt64(m.ServerGUID, byteorder.BigEndian); err != nil {
+		return err
+	}
+
+	if err := b.WriteBool(m.Security); err != nil {
+		return err
+	}
+
+	if m.Security {
+		if err := b.WriteUint32(m.Cookie, byteorder.BigEndian); err != nil {
+			return err
+		}
+	}
+
+	return b.WriteUint16(m.MTU, byteorder.BigEndian)
+}
+
+// Reads the message from the buffer and returns an error if the operation was unsuccessful
+func (m *OpenConnectionReply1) Decode(b *buffer.Buffer) (err error) {
+	if err := readID(b, IDOpenConnectionReply1); err != nil {
+		return err
+	}
+
+	if err := b.ReadMagic(); err != nil {
+		return err
+	}
+
+	if m.ServerGUID, err = b.ReadInt64(byteorder.BigEndian); err != nil {
+		return buffer.WithField(err, "server guid")
+	}
+
+	if m.Security, err = b.ReadBool(); err != nil {
+		return buffer.WithField(err, "security")
+	}
+
+	m.Cookie = 0
+	if m.Security {
+		if m.Cookie, err = b.ReadUint32(byteorder.BigEndian); err != nil {
+			return buffer.WithField(err, "cookie")
+		}
+	}
+
+	if m.MTU, err = b.ReadUint16(byteorder.BigEndian); err != nil {
+		return buffer.WithField(err, "mtu")
+	}
+
+	return nil
+}
+
+// OpenConnectionRequest2 is the second message of the handshake
+type OpenConnectionRequest2 struct {
+	// Security reports whether the message answers a server that requires a cookie, in which case Cookie and
+	// Challenge are present. Nothing on the wire tells, so it must be set before decoding to the Security of
+	// the OpenConnectionReply1 the message answers.
+	Security bool
+	Cookie   uint32
+	// Challenge is the client's 64 byte answer to the cookie, or nil if the client does not support it.
+	// Decoding sets it to a shared reference to the buffer's internal slice.
+	Challenge []byte
+
+	ServerAddress net.UDPAddr
+	MTU           uint16
+	ClientGUID    int64
+}
+
+// Returns the id of the message
+func (m *OpenConnectionRequest2) ID() uint8 {
+	return IDOpenConnectionRequest2
+}
+
+// Writes the message to the buffer and returns an error if the operation was unsuccessful
+func (m *OpenConnectionRequest2) Encode(b *buffer.Buffer) error {
+	if err := b.WriteUint8(IDOpenConnectionRequest2); err != nil {
+		return err
+	}
+
+	if err := b.WriteMagic(); err != nil {
+		return err
+	}
+
+	if m.Security {
+		if len(m.Challenge) != 0 && len(m.Challenge) != challengeSize {
+			return fmt.Errorf("challenge: %w", buffer.ErrInvalidLength)
+		}
+
+		if err := b.WriteUint32(m.Cookie, byteorder.BigEndian); err != nil {
+			return err
+		}
+
+		if err := b.WriteBool(len(m.Challenge) != 0); err != nil {
+			return err
+		}
+
+		if _, err := b.Write(m.Challenge); err != nil {
+			return err
+		}
+	}
+
+	if err := b.WriteAddr(&m.ServerAddress); err != nil {
+		return err
+	}
+
+	if err := b.WriteUint16(m.MTU, byteorder.BigEndian); err != nil {
+		return err
+	}
+
+	return b.WriteInt64(m.ClientGUID, byteorder.BigEndian)
+}
+
+// Reads the message from the buffer and returns an error if the operation was unsuccessful. The cookie is
+// read only if Security is set.
+func (m *OpenConnectionRequest2) Decode(b *buffer.Buffer) (err error) {
+	if err := readID(b, IDOpenConnectionRequest2); err != nil {
+		return err
+	}
+
+	if err := b.ReadMagic(); err != nil {
+		return err
+	}
+
+	m.Cookie, m.Challenge = 0, nil
+	if m.Security {
+		if m.Cookie, err = b.ReadUint32(byteorder.BigEndian); err != nil {
+			return buffer.WithField(err, "cookie")
+		}
+
+		ok, err := b.ReadBool()
+		if err != nil {
+			return buffer.WithField(err, "challenge")
+		}
+
+		if ok {
+			if b.Remaining() < challengeSize {
+				return &buffer.DecodeError{Offset: b.Offset(), Size: challengeSize, Remaining: b.Remaining(), Field: "challenge", Err: buffer.ErrEndOfFile}
+			}
+
+			if m.Challenge, err = b.Get(challengeSize); err != nil {
+				return buffer.WithField(err, "challenge")
+			}
+		}
+	}
+
+	if err := b.ReadAddr(&m.ServerAddress); err != nil {
+		return buffer.WithField(err, "server address")
+	}
+
+	if m.MTU, err = b.ReadUint16(byteorder.BigEndian); err != nil {
+		return buffer.WithField(err, "mtu")
+	}
+
+	if m.ClientGUID, err = b.ReadInt64(byteorder.BigEndian); err != nil {
+		return buffer.WithField(err, "client guid")
+	}
+
+	return nil
+}
+
+// OpenConnectionReply2 is the last message of the offline handshake, after which the client sends a
+// ConnectionRequest inside of a frame set
+type OpenConnectionReply2 struct {
+	ServerGUID    int64
+	ClientAddress net.UDPAddr
+	MTU           uint16
+	// Encryption reports whether the connection is encrypted
+	Encryption bool
+}
+
+// Returns the id of the message
+func (m *OpenConnectionReply2) ID() uint8 {
+	return IDOpenConnectionReply2
+}
+
+// Writes the message to the buffer and returns an error if the operation was unsuccessful
+func (m *OpenConnectionReply2) Encode(b *buffer.Buffer) error {
+	if err := b.WriteUint8(IDOpenConnectionReply2); err != nil {
+		return err
+	}
+
+	if err := b.WriteMagic(); err != nil {
+		return err
+	}
+
+	if err := b.WriteInt64(m.ServerGUID, byteorder.BigEndian); err != nil {
+		return err
+	}
+
+	if err := b.WriteAddr(&m.ClientAddress); err != nil {
+		return err
+	}
+
+	if err := b.WriteUint16(m.MTU, byteorder.BigEndian); err != nil {
+		return err
+	}
+
+	return b.WriteBool(m.Encryption)
+}
+
+// Reads the message from the buffer and returns an error if the operation was unsuccessful
+func (m *OpenConnectionReply2) Decode(b *buffer.Buffer) (err error) {
+	if err := readID(b, IDOpenConnectionReply2); err != nil {
+		return err
+	}
+
+	if err := b.ReadMagic(); err != nil {
+		return err
+	}
+
+	if m.ServerGUID, err = b.ReadInt64(byteorder.BigEndian); err != nil {
+		return buffer.WithField(err, "server guid")
+	}
+
+	if err := b.ReadAddr(&m.ClientAddress); err != nil {
+		return buffer.WithField(err, "client address")
+	}
+
+	if m.MTU, err = b.ReadUint16(byteorder.BigEndian); err != nil {
+		return buffer.WithField(err, "mtu")
+	}
+
+	if m.Encryption, err = b.ReadBool(); err != nil {
+		return buffer.WithField(err, "encryption")
+	}
+
+	return nil
+}
+
+// IncompatibleProtocolVersion is sent by servers in reply to an OpenConnectionRequest1 of another protocol
+type IncompatibleProtocolVersion struct {
+	// Protocol is the protocol the server supports
+	Protocol   uint8
+	ServerGUID int64
+}
+
+// Returns the id of the message
+func (m *IncompatibleProtocolVersion) ID() uint8 {
+	return IDIncompatibleProtocolVersion
+}
+
+// Writes the message to the buffer and returns an error if the operation was unsuccessful
+func (m *IncompatibleProtocolVersion) Encode(b *buffer.Buffer) error {
+	if err := b.WriteUint8(IDIncompatibleProtocolVersion); err != nil {
+		return err
+	}
+
+	if err := b.WriteUint8(m.Protocol); err != nil {
+		return err
+	}
+
+	if err := b.WriteMagic(); err != nil {
+		return err
+	}
+
+	return b.WriteInt64(m.ServerGUID, byteorder.BigEndian)
+}
+
+// Reads the message from the buffer and returns an error if the operation was unsuccessful
+func (m *IncompatibleProtocolVersion) Decode(b *buffer.Buffer) (err error) {
+	if err := readID(b, IDIncompatibleProtocolVersion); err != nil {
+		return err
+	}
+
+	if m.Protocol, err = b.ReadUint8(); err != nil {
+		return buffer.WithField(err, "protocol")
+	}
+
+	if err := b.ReadMagic(); err != nil {
+		return err
+	}
+
+	if m.ServerGUID, err = b.ReadInt64(byteorder.BigEndian); err != nil {
+		return buffer.WithField(err, "server guid")
+	}
+
+	return nil
+}
+
+// AlreadyConnected is sent by servers in reply to a handshake from an address that is already connected
+type AlreadyConnected struct {
+	ServerGUID int64
+}
+
+// Returns the id of the message
+func (m *AlreadyConnected) ID() uint8 {
+	return IDAlreadyConnected
+}
+
+// Writes the message to the buffer and returns an error if the operation was unsuccessful
+func (m *AlreadyConnected) Encode(b *buffer.Buffer) error {
+	if err := b.WriteUint8(IDAlreadyConnected); err != nil {
+		return err
+	}
+
+	if err := b.WriteMagic(); err != nil {
+		return err
+	}
+
+	return b.WriteInt64(m.ServerGUID, byteorder.BigEndian)
+}
+
+// Reads the message from the buffer and returns an error if the operation was unsuccessful
+func (m *AlreadyConnected) Decode(b *buffer.Buffer) (err error) {
+	if err := readID(b, IDAlreadyConnected); err != nil {
+		return err
+	}
+
+	if err := b.ReadMagic(); err != nil {
+		return err
+	}
+
+	if m.ServerGUID, err = b.ReadInt64(byteorder.BigEndian); err != nil {
+		return buffer.WithField(err, "server guid")
+	}
+
+	return nil
+}
@@ -0,0 +1,138 @@
+package raknet
+
+import (
+	"bytes"
+	"errors"
+	"net"
+	"reflect"
+	"testing"
+
+	"github.com/gamevidea/binary/buffer"
+)
+
+// Encodes m, decodes the result into got and fails the test unless got equals m and every byte was read.
+// It returns the encoded message.
+func roundTrip(t *testing.T, m, got Message) []byte {
+	t.Helper()
+
+	b := buffer.NewGrowable(0)
+	if err := m.Encode(b); err != nil {
+		t.Fatalf("%T.Encode() error = %v", m, err)
+	}
+	b.Resize(b.Offset())
+	b.SetOffset(0)
+
+	if err := got.Decode(b); err != nil {
+		t.Fatalf("%T.Decode() error = %v", got, err)
+	}
+
+	if !reflect.DeepEqual(got, m) {
+		t.Fatalf("%T.Decode() = %+v, want %+v", got, got, m)
+	}
+
+	if b.Remaining() != 0 {
+		t.Fatalf("%T.Decode() left %d bytes", got, b.Remaining())
+	}
+
+	if data := b.Bytes(); len(data) == 0 || data[0] != m.ID() {
+		t.Fatalf("%T.Encode() did not start with id %#x", m, m.ID())
+	}
+
+	return b.Bytes()
+}
+
+func TestOfflineRoundTrip(t *testing.T) {
+	v4 := net.UDPAddr{IP: net.IPv4(192, 168, 1, 2), Port: 19132}
+	v6 := net.UDPAddr{IP: net.ParseIP("2001:db8::1"), Port: 19133}
+
+	tests := []struct {
+		m, got Message
+	}{
+		{&UnconnectedPing{Time: 1, ClientGUID: -2}, &UnconnectedPing{}},
+		{&UnconnectedPing{OpenConnections: true, Time: 3, ClientGUID: 4}, &UnconnectedPing{}},
+		{&UnconnectedPong{Time: 5, ServerGUID: 6, Data: "MCPE;Dedicated Server;712;1.21.20;0;10;6;Bedrock level;Survival;1;19132;19133;"}, &UnconnectedPong{}},
+		{&OpenConnectionRequest1{Protocol: 11, MTU: 1492}, &OpenConnectionRequest1{}},
+		{&OpenConnectionReply1{ServerGUID: 7, MTU: 1400}, &OpenConnectionReply1{}},
+		{&OpenConnectionReply1{ServerGUID: 8, Security: true, Cookie: 0xdeadbeef, MTU: 1400}, &OpenConnectionReply1{}},
+		{&OpenConnectionRequest2{ServerAddress: v4, MTU: 1400, ClientGUID: 9}, &OpenConnectionRequest2{}},
+		{&OpenConnectionRequest2{ServerAddress: v6, MTU: 1400, ClientGUID: 10}, &OpenConnectionRequest2{}},
+		{&OpenConnectionReply2{ServerGUID: 11, ClientAddress: v4, MTU: 1400, Encryption: true}, &OpenConnectionReply2{}},
+		{&OpenConnectionReply2{ServerGUID: 12, ClientAddress: v6, MTU: 576}, &OpenConnectionReply2{}},
+		{&IncompatibleProtocolVersion{Protocol: 10, ServerGUID: 13}, &IncompatibleProtocolVersion{}},
+		{&AlreadyConnected{ServerGUID: 14}, &AlreadyConnected{}},
+	}
+
+	for _, tt := range tests {
+		roundTrip(t, tt.m, tt.got)
+	}
+}
+
+func TestOpenConnectionRequest1MTU(t *testing.T) {
+	for _, mtu := range []uint16{mtuOverhead + 18, 576, 1400, 1492} {
+		data := roundTrip(t, &OpenConnectionRequest1{Protocol: 11, MTU: mtu}, &OpenConnectionRequest1{})
+
+		// The id, magic and protocol are followed by the padding that fills the mtu.
+		if len(data) != int(mtu)-mtuOverhead {
+			t.Fatalf("Encode() with mtu %d wrote %d bytes, want %d", mtu, len(data), int(mtu)-mtuOverhead)
+		}
+
+		if padding := data[18:]; len(padding) != int(mtu)-28-18 || bytes.Count(padding, []byte{0}) != len(padding) {
+			t.Fatalf("Encode() with mtu %d wrote a padding of %d bytes, want %d zeros", mtu, len(padding), int(mtu)-28-18)
+		}
+	}
+
+	// The mtu is derived from the padding that was received, not from the one that was sent.
+	data := roundTrip(t, &OpenConnectionRequest1{Protocol: 11, MTU: 1492}, &OpenConnectionRequest1{})
+
+	got := &OpenConnectionRequest1{}
+	if err := got.Decode(buffer.From(data[:1000])); err != nil || got.MTU != 1000+mtuOverhead {
+		t.Fatalf("Decode() of a truncated padding = %d, %v, want mtu %d", got.MTU, err, 1000+mtuOverhead)
+	}
+
+	if err := (&OpenConnectionRequest1{MTU: mtuOverhead + 17}).Encode(buffer.NewGrowable(0)); !errors.Is(err, ErrInvalidMTU) {
+		t.Fatalf("Encode() of a too small mtu error = %v, want ErrInvalidMTU", err)
+	}
+}
+
+func TestOpenConnectionRequest2Cookie(t *testing.T) {
+	challenge := bytes.Repeat([]byte{0xab}, challengeSize)
+
+	for _, addr := range []net.UDPAddr{{IP: net.IPv4(10, 0, 0, 1), Port: 19132}, {IP: net.ParseIP("fe80::1"), Port: 19132}} {
+		tests := []*OpenConnectionRequest2{
+			{ServerAddress: addr, MTU: 1400, ClientGUID: 1},
+			{Security: true, Cookie: 0x01020304, ServerAddress: addr, MTU: 1400, ClientGUID: 2},
+			{Security: true, Cookie: 0x01020304, Challenge: challenge, ServerAddress: addr, MTU: 1400, ClientGUID: 3},
+		}
+
+		for _, m := range tests {
+			// The caller tells whether the cookie is present from the OpenConnectionReply1 it answers.
+			data := roundTrip(t, m, &OpenConnectionRequest2{Security: m.Security})
+
+			want := 1 + 16 + buffer.AddrSize(&addr) + 2 + 8
+			if m.Security {
+				want += 4 + 1 + len(m.Challenge)
+			}
+
+			if len(data) != want {
+				t.Fatalf("Encode() with security %v wrote %d bytes, want %d", m.Security, len(data), want)
+			}
+		}
+	}
+
+	// A stale cookie and challenge are cleared when decoding a message without them.
+	got := &OpenConnectionRequest2{Cookie: 1, Challenge: challenge}
+	roundTrip(t, &OpenConnectionRequest2{ServerAddress: net.UDPAddr{IP: net.IPv4(10, 0, 0, 1), Port: 1}}, got)
+
+	if err := (&OpenConnectionRequest2{Security: true, Challenge: challenge[1:]}).Encode(buffer.NewGrowable(0)); !errors.Is(err, buffer.ErrInvalidLength) {
+		t.Fatalf("Encode() of a short challenge error = %v, want ErrInvalidLength", err)
+	}
+}
+
+func TestOfflineInvalidID(t *testing.T) {
+	data := roundTrip(t, &AlreadyConnected{ServerGUID: 1}, &AlreadyConnected{})
+
+	var decodeErr *buffer.DecodeError
+	if err := (&IncompatibleProtocolVersion{}).Decode(buffer.From(data)); !errors.Is(err, ErrInvalidID) || !errors.As(err, &decodeErr) || decodeErr.Offset != 0 {
+		t.Fatalf("Decode() of another message error = %v, want a *DecodeError wrapping ErrInvalidID at offset 0", err)
+	}
+}
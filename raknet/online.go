@@ -0,0 +1,317 @@
+package raknet
+
+import (
+	"net"
+
+	"github.com/gamevidea/binary/buffer"
+	"github.com/gamevidea/binary/byteorder"
+)
+
+// ConnectedPing is sent by either side of a connection to measure its latency
+type ConnectedPing struct {
+	Time int64
+}
+
+// Returns the id of the message
+func (m *ConnectedPing) ID() uint8 {
+	return IDConnectedPing
+}
+
+// Writes the message to the buffer and returns an error if the operation was unsuccessful
+func (m *ConnectedPing) Encode(b *buffer.Buffer) error {
+	if err := b.WriteUint8(IDConnectedPing); err != nil {
+		return err
+	}
+
+	return b.WriteInt64(m.Time, byteorder.BigEndian)
+}
+
+// Reads the message from the buffer and returns an error if the operation was unsuccessful
+func (m *ConnectedPing) Decode(b *buffer.Buffer) (err error) {
+	if err := readID(b, IDConnectedPing); err != nil {
+		return err
+	}
+
+	if m.Time, err = b.ReadInt64(byteorder.BigEndian); err != nil {
+		return buffer.WithField(err, "time")
+	}
+
+	return nil
+}
+
+// ConnectedPong is sent in reply to a ConnectedPing
+type ConnectedPong struct {
+	// PingTime is the time of the ping being replied to
+	PingTime int64
+	PongTime int64
+}
+
+// Returns the id of the message
+func (m *ConnectedPong) ID() uint8 {
+	return IDConnectedPong
+}
+
+// Writes the message to the buffer and returns an error if the operation was unsuccessful
+func (m *ConnectedPong) Encode(b *buffer.Buffer) error {
+	if err := b.WriteUint8(IDConnectedPong); err != nil {
+		return err
+	}
+
+	if err := b.WriteInt64(m.PingTime, byteorder.BigEndian); err != nil {
+		return err
+	}
+
+	return b.WriteInt64(m.PongTime, byteorder.BigEndian)
+}
+
+// Reads the message from the buffer and returns an error if the operation was unsuccessful
+func (m *ConnectedPong) Decode(b *buffer.Buffer) (err error) {
+	if err := readID(b, IDConnectedPong); err != nil {
+		return err
+	}
+
+	if m.PingTime, err = b.ReadInt64(byteorder.BigEndian); err != nil {
+		return buffer.WithField(err, "ping time")
+	}
+
+	if m.PongTime, err = b.ReadInt64(byteorder.BigEndian); err != nil {
+		return buffer.WithField(err, "pong time")
+	}
+
+	return nil
+}
+
+// ConnectionRequest is the first message sent by clients inside of a frame set
+type ConnectionRequest struct {
+	ClientGUID int64
+	Time       int64
+	// Security reports whether the client answers a server that requires security, in which case Proof
+	// holds the rest of the message. Decoding sets Proof to a shared reference to the buffer's internal slice.
+	Security bool
+	Proof    []byte
+}
+
+// Returns the id of the message
+func (m *ConnectionRequest) ID() uint8 {
+	return IDConnectionRequest
+}
+
+// Writes the message to the buffer and returns an error if the operation was unsuccessful
+func (m *ConnectionRequest) Encode(b *buffer.Buffer) error {
+	if err := b.WriteUint8(IDConnectionRequest); err != nil {
+		return err
+	}
+
+	if err := b.WriteInt64(m.ClientGUID, byteorder.BigEndian); err != nil {
+		return err
+	}
+
+	if err := b.WriteInt64(m.Time, byteorder.BigEndian); err != nil {
+		return err
+	}
+
+	if err := b.WriteBool(m.Security); err != nil {
+		return err
+	}
+
+	if !m.Security {
+		return nil
+	}
+
+	_, err := b.Write(m.Proof)
+	return err
+}
+
+// Reads the message from the buffer and returns an error if the operation was unsuccessful
+func (m *ConnectionRequest) Decode(b *buffer.Buffer) (err error) {
+	if err := readID(b, IDConnectionRequest); err != nil {
+		return err
+	}
+
+	if m.ClientGUID, err = b.ReadInt64(byteorder.BigEndian); err != nil {
+		return buffer.WithField(err, "client guid")
+	}
+
+	if m.Time, err = b.ReadInt64(byteorder.BigEndian); err != nil {
+		return buffer.WithField(err, "time")
+	}
+
+	if m.Security, err = b.ReadBool(); err != nil {
+		return buffer.WithField(err, "security")
+	}
+
+	m.Proof = nil
+	if m.Security && b.Remaining() > 0 {
+		if m.Proof, err = b.Get(b.Remaining()); err != nil {
+			return buffer.WithField(err, "proof")
+		}
+	}
+
+	return nil
+}
+
+// ConnectionRequestAccepted is sent by servers in reply to a ConnectionRequest
+type ConnectionRequestAccepted struct {
+	ClientAddress net.UDPAddr
+	SystemIndex   uint16
+	// SystemAddresses are the addresses of the server. Decoding reuses the slice across calls.
+	SystemAddresses []net.UDPAddr
+	// SystemAddressCount is the number of system addresses written and read, as it is not on the wire. Zero
+	// means buffer.SystemAddressesMCPE, while regular RakNet uses buffer.SystemAddressesRakNet.
+	SystemAddressCount int
+	// RequestTime is the time of the ConnectionRequest being replied to
+	RequestTime  int64
+	AcceptedTime int64
+}
+
+// Returns the id of the message
+func (m *ConnectionRequestAccepted) ID() uint8 {
+	return IDConnectionRequestAccepted
+}
+
+// Writes the message to the buffer and returns an error if the operation was unsuccessful. Placeholder
+// addresses are written in place of the system addresses that are not provided.
+func (m *ConnectionRequestAccepted) Encode(b *buffer.Buffer) error {
+	if err := b.WriteUint8(IDConnectionRequestAccepted); err != nil {
+		return err
+	}
+
+	if err := b.WriteAddr(&m.ClientAddress); err != nil {
+		return err
+	}
+
+	if err := b.WriteUint16(m.SystemIndex, byteorder.BigEndian); err != nil {
+		return err
+	}
+
+	if err := b.WriteSystemAddresses(m.SystemAddresses, systemAddressCount(m.SystemAddressCount)); err != nil {
+		return err
+	}
+
+	if err := b.WriteInt64(m.RequestTime, byteorder.BigEndian); err != nil {
+		return err
+	}
+
+	return b.WriteInt64(m.AcceptedTime, byteorder.BigEndian)
+}
+
+// Reads the message from the buffer and returns an error if the operation was unsuccessful
+func (m *ConnectionRequestAccepted) Decode(b *buffer.Buffer) (err error) {
+	if err := readID(b, IDConnectionRequestAccepted); err != nil {
+		return err
+	}
+
+	if err := b.ReadAddr(&m.ClientAddress); err != nil {
+		return buffer.WithField(err, "client address")
+	}
+
+	if m.SystemIndex, err = b.ReadUint16(byteorder.BigEndian); err != nil {
+		return buffer.WithField(err, "system index")
+	}
+
+	if m.SystemAddresses, err = b.ReadSystemAddresses(m.SystemAddresses[:0], systemAddressCount(m.SystemAddressCount)); err != nil {
+		return buffer.WithField(err, "system addresses")
+	}
+
+	if m.RequestTime, err = b.ReadInt64(byteorder.BigEndian); err != nil {
+		return buffer.WithField(err, "request time")
+	}
+
+	if m.AcceptedTime, err = b.ReadInt64(byteorder.BigEndian); err != nil {
+		return buffer.WithField(err, "accepted time")
+	}
+
+	return nil
+}
+
+// NewIncomingConnection is sent by clients in reply to a ConnectionRequestAccepted, completing the handshake
+type NewIncomingConnection struct {
+	ServerAddress net.UDPAddr
+	// SystemAddresses are the addresses of the client. Decoding reuses the slice across calls.
+	SystemAddresses []net.UDPAddr
+	// SystemAddressCount is the number of system addresses written and read, as it is not on the wire. Zero
+	// means buffer.SystemAddressesMCPE, while regular RakNet uses buffer.SystemAddressesRakNet.
+	SystemAddressCount int
+	// RequestTime is the time of the ConnectionRequestAccepted being replied to
+	RequestTime  int64
+	AcceptedTime int64
+}
+
+// Returns the id of the message
+func (m *NewIncomingConnection) ID() uint8 {
+	return IDNewIncomingConnection
+}
+
+// Writes the message to the buffer and returns an error if the operation was unsuccessful. Placeholder
+// addresses are written in place of the system addresses that are not provided.
+func (m *NewIncomingConnection) Encode(b *buffer.Buffer) error {
+	if err := b.WriteUint8(IDNewIncomingConnection); err != nil {
+		return err
+	}
+
+	if err := b.WriteAddr(&m.ServerAddress); err != nil {
+		return err
+	}
+
+	if err := b.WriteSystemAddresses(m.SystemAddresses, systemAddressCount(m.SystemAddressCount)); err != nil {
+		return err
+	}
+
+	if err := b.WriteInt64(m.RequestTime, byteorder.BigEndian); err != nil {
+		return err
+	}
+
+	return b.WriteInt64(m.AcceptedTime, byteorder.BigEndian)
+}
+
+// Reads the message from the buffer and returns an error if the operation was unsuccessful
+func (m *NewIncomingConnection) Decode(b *buffer.Buffer) (err error) {
+	if err := readID(b, IDNewIncomingConnection); err != nil {
+		return err
+	}
+
+	if err := b.ReadAddr(&m.ServerAddress); err != nil {
+		return buffer.WithField(err, "server address")
+	}
+
+	if m.SystemAddresses, err = b.ReadSystemAddresses(m.SystemAddresses[:0], systemAddressCount(m.SystemAddressCount)); err != nil {
+		return buffer.WithField(err, "system addresses")
+	}
+
+	if m.RequestTime, err = b.ReadInt64(byteorder.BigEndian); err != nil {
+		return buffer.WithField(err, "request time")
+	}
+
+	if m.AcceptedTime, err = b.ReadInt64(byteorder.BigEndian); err != nil {
+		return buffer.WithField(err, "accepted time")
+	}
+
+	return nil
+}
+
+// DisconnectNotification is sent by either side of a connection when it is closed
+type DisconnectNotification struct{}
+
+// Returns the id of the message
+func (m *DisconnectNotification) ID() uint8 {
+	return IDDisconnectNotification
+}
+
+// Writes the message to the buffer and returns an error if the operation was unsuccessful
+func (m *DisconnectNotification) Encode(b *buffer.Buffer) error {
+	return b.WriteUint8(IDDisconnectNotification)
+}
+
+// Reads the message from the buffer and returns an error if the operation was unsuccessful
+func (m *DisconnectNotification) Decode(b *buffer.Buffer) error {
+	return readID(b, IDDisconnectNotification)
+}
+
+// Returns the number of system addresses of a message, resolving zero to the MCPE count
+func systemAddressCount(n int) int {
+	if n == 0 {
+		return buffer.SystemAddressesMCPE
+	}
+
+	return n
+}
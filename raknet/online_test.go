@@ -0,0 +1,119 @@
+package raknet
+
+import (
+	"errors"
+	"net"
+	"testing"
+
+	"github.com/gamevidea/binary/buffer"
+)
+
+func TestSystemAddressCount(t *testing.T) {
+	addrs := []net.UDPAddr{{IP: net.IPv4(10, 0, 0, 1).To4(), Port: 19132}}
+
+	for _, count := range []int{0, buffer.SystemAddressesRakNet, buffer.SystemAddressesMCPE} {
+		want := count
+		if want == 0 {
+			want = buffer.SystemAddressesMCPE
+		}
+
+		m := &NewIncomingConnection{
+			ServerAddress:      net.UDPAddr{IP: net.IPv4(127, 0, 0, 1).To4(), Port: 19132},
+			SystemAddresses:    addrs,
+			SystemAddressCount: count,
+			RequestTime:        1,
+			AcceptedTime:       2,
+		}
+
+		b := buffer.NewGrowable(0)
+		if err := m.Encode(b); err != nil {
+			t.Fatalf("Encode() with count %d error = %v", count, err)
+		}
+		b.Resize(b.Offset())
+		b.SetOffset(0)
+
+		got := &NewIncomingConnection{SystemAddressCount: count}
+		if err := got.Decode(b); err != nil {
+			t.Fatalf("Decode() with count %d error = %v", count, err)
+		}
+
+		if len(got.SystemAddresses) != want || !got.SystemAddresses[0].IP.Equal(addrs[0].IP) {
+			t.Fatalf("Decode() with count %d read %d system addresses, want %d", count, len(got.SystemAddresses), want)
+		}
+
+		if got.RequestTime != 1 || got.AcceptedTime != 2 || b.Remaining() != 0 {
+			t.Fatalf("Decode() with count %d read times %d and %d with %d bytes left", count, got.RequestTime, got.AcceptedTime, b.Remaining())
+		}
+	}
+}
+
+func TestSystemAddressCountMismatch(t *testing.T) {
+	m := &ConnectionRequestAccepted{SystemAddressCount: buffer.SystemAddressesRakNet}
+
+	b := buffer.NewGrowable(0)
+	if err := m.Encode(b); err != nil {
+		t.Fatalf("Encode() error = %v", err)
+	}
+	b.Resize(b.Offset())
+	b.SetOffset(0)
+
+	// The timestamps are read as addresses of an unknown version when more addresses are expected.
+	got := &ConnectionRequestAccepted{SystemAddresses: make([]net.UDPAddr, 0, buffer.SystemAddressesMCPE)}
+	if err := got.Decode(b); err == nil {
+		t.Fatal("Decode() of 10 system addresses expecting 20 succeeded")
+	}
+
+	m.SystemAddresses = make([]net.UDPAddr, buffer.SystemAddressesRakNet+1)
+	if err := m.Encode(buffer.NewGrowable(0)); !errors.Is(err, buffer.ErrLengthMismatch) {
+		t.Fatalf("Encode() of 11 system addresses with count 10 error = %v, want ErrLengthMismatch", err)
+	}
+}
+
+func TestOnlineRoundTrip(t *testing.T) {
+	addrs := make([]net.UDPAddr, buffer.SystemAddressesRakNet)
+	for i := range addrs {
+		addrs[i] = net.UDPAddr{IP: net.IPv4(10, 0, 0, byte(i)), Port: 19132 + i}
+	}
+	addrs[1] = net.UDPAddr{IP: net.ParseIP("2001:db8::1"), Port: 19133}
+
+	tests := []struct {
+		m, got Message
+	}{
+		{&ConnectedPing{Time: 1}, &ConnectedPing{}},
+		{&ConnectedPong{PingTime: 2, PongTime: -3}, &ConnectedPong{}},
+		{&ConnectionRequest{ClientGUID: 4, Time: 5}, &ConnectionRequest{}},
+		{&ConnectionRequest{ClientGUID: 6, Time: 7, Security: true}, &ConnectionRequest{}},
+		{&ConnectionRequest{ClientGUID: 8, Time: 9, Security: true, Proof: []byte{1, 2, 3, 4}}, &ConnectionRequest{Proof: []byte{0xff}}},
+		{
+			&ConnectionRequestAccepted{
+				ClientAddress:      net.UDPAddr{IP: net.IPv4(192, 168, 1, 2), Port: 54321},
+				SystemIndex:        10,
+				SystemAddresses:    addrs,
+				SystemAddressCount: buffer.SystemAddressesRakNet,
+				RequestTime:        11,
+				AcceptedTime:       12,
+			},
+			&ConnectionRequestAccepted{SystemAddressCount: buffer.SystemAddressesRakNet},
+		},
+		{&DisconnectNotification{}, &DisconnectNotification{}},
+	}
+
+	for _, tt := range tests {
+		roundTrip(t, tt.m, tt.got)
+	}
+}
+
+func TestConnectionRequestProofIsShared(t *testing.T) {
+	data := roundTrip(t, &ConnectionRequest{Security: true, Proof: []byte{1, 2}}, &ConnectionRequest{})
+
+	got := &ConnectionRequest{}
+	if err := got.Decode(buffer.From(data)); err != nil {
+		t.Fatal(err)
+	}
+
+	// The proof is the rest of the message and refers to the buffer's internal slice.
+	data[len(data)-1] = 0xff
+	if got.Proof[1] != 0xff {
+		t.Fatal("Decode() copied the proof instead of sharing the buffer's slice")
+	}
+}
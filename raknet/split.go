@@ -0,0 +1,259 @@
+package raknet
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/gamevidea/binary/buffer"
+)
+
+const (
+	// DefaultMaxSplits is the maximum number of split packets being reassembled at once when no limit is
+	// configured
+	DefaultMaxSplits = 16
+	// DefaultMaxSplitFragments is the maximum number of fragments of a split packet when no limit is
+	// configured
+	DefaultMaxSplitFragments = 512
+	// DefaultMaxSplitBytes is the maximum number of bytes reserved by all split packets being reassembled
+	// at once when no limit is configured
+	DefaultMaxSplitBytes = 8 << 20
+	// DefaultSplitTimeout is the time after which a split packet that received no fragment is discarded when
+	// no timeout is configured
+	DefaultSplitTimeout = 10 * time.Second
+)
+
+// ErrInvalidSplit is the error returned when a fragment does not match the split packet it belongs to
+var ErrInvalidSplit = errors.New("could not reassemble the split packet as the fragment is inconsistent")
+
+// ErrTooManySplits is the error returned when a fragment would exceed the limits on split packets
+var ErrTooManySplits = errors.New("could not reassemble the split packet as it exceeds the split limits")
+
+// Reassembler joins the fragments of split packets back together. Fragments are written into a buffer
+// pre-sized from the fragment size and count, and the limits below bound the memory a single connection
+// can hold on to. A Reassembler belongs to a single connection and is not safe for concurrent use.
+type Reassembler struct {
+	// MaxSplits is the maximum number of split packets reassembled at once. Zero means DefaultMaxSplits.
+	MaxSplits int
+	// MaxFragments is the maximum number of fragments of a split packet. Zero means DefaultMaxSplitFragments.
+	MaxFragments int
+	// MaxBytes is the maximum number of bytes reserved by all split packets reassembled at once. Zero means
+	// DefaultMaxSplitBytes.
+	MaxBytes int
+	// Timeout is the time after which a split packet that received no fragment is discarded. Zero means
+	// DefaultSplitTimeout.
+	Timeout time.Duration
+
+	splits map[uint16]*split
+	bytes  int
+}
+
+// split is a split packet being reassembled
+type split struct {
+	// buf holds the packet. It is allocated once the size of the fragments is known.
+	buf *buffer.Buffer
+	// size is the size of every fragment except the last one
+	size int
+	// last holds a copy of the last fragment if it arrived before the size of the fragments was known
+	last []byte
+	// received reports which fragments were received
+	received []bool
+	// count is the number of fragments received
+	count int
+	// length is the length of the packet, known once the last fragment is received
+	length int
+	// reserved is the number of bytes accounted against the limits
+	reserved int
+	// updated is the time the last fragment was received
+	updated time.Time
+}
+
+// Returns the configured maximum number of split packets or the default one
+func (r *Reassembler) maxSplits() int {
+	if r.MaxSplits > 0 {
+		return r.MaxSplits
+	}
+
+	return DefaultMaxSplits
+}
+
+// Returns the configured maximum number of fragments or the default one
+func (r *Reassembler) maxFragments() int {
+	if r.MaxFragments > 0 {
+		return r.MaxFragments
+	}
+
+	return DefaultMaxSplitFragments
+}
+
+// Returns the configured maximum number of bytes or the default one
+func (r *Reassembler) maxBytes() int {
+	if r.MaxBytes > 0 {
+		return r.MaxBytes
+	}
+
+	return DefaultMaxSplitBytes
+}
+
+// Returns the configured timeout or the default one
+func (r *Reassembler) timeout() time.Duration {
+	if r.Timeout > 0 {
+		return r.Timeout
+	}
+
+	return DefaultSplitTimeout
+}
+
+// Returns the number of split packets being reassembled
+func (r *Reassembler) Pending() int {
+	return len(r.splits)
+}
+
+// Adds the content of the provided split frame to its split packet. It returns the reassembled packet once
+// every fragment was received, with its offset at 0 and its length resized to the packet's length, or nil
+// if fragments are still missing. Duplicate fragments are ignored. Stale split packets are discarded before
+// the frame is processed, and a split packet that receives an inconsistent fragment is discarded as well.
+func (r *Reassembler) Add(f *Frame) (*buffer.Buffer, error) {
+	now := time.Now()
+	r.Expire(now)
+
+	if !f.Split || f.SplitCount == 0 || f.SplitIndex >= f.SplitCount || len(f.Content) == 0 {
+		return nil, fmt.Errorf("split %d: %w", f.SplitID, ErrInvalidSplit)
+	}
+
+	if int(f.SplitCount) > r.maxFragments() {
+		return nil, fmt.Errorf("split %d: %d fragments: %w", f.SplitID, f.SplitCount, ErrTooManySplits)
+	}
+
+	s, ok := r.splits[f.SplitID]
+	if !ok {
+		if len(r.splits) >= r.maxSplits() {
+			return nil, fmt.Errorf("split %d: %w", f.SplitID, ErrTooManySplits)
+		}
+
+		if r.splits == nil {
+			r.splits = make(map[uint16]*split)
+		}
+
+		s = &split{received: make([]bool, f.SplitCount)}
+		r.splits[f.SplitID] = s
+	}
+
+	if err := r.add(s, f); err != nil {
+		r.discard(f.SplitID)
+		return nil, fmt.Errorf("split %d: %w", f.SplitID, err)
+	}
+
+	s.updated = now
+	if s.count < len(s.received) {
+		return nil, nil
+	}
+
+	r.discard(f.SplitID)
+
+	s.buf.Resize(s.length)
+	s.buf.SetOffset(0)
+
+	return s.buf, nil
+}
+
+// Writes the fragment into the split packet, allocating its buffer once the size of the fragments is known
+func (r *Reassembler) add(s *split, f *Frame) error {
+	count, index := int(f.SplitCount), int(f.SplitIndex)
+	if count != len(s.received) {
+		return ErrInvalidSplit
+	}
+
+	if s.received[index] {
+		return nil
+	}
+
+	lastIndex := index == count-1
+	if s.buf == nil {
+		if lastIndex && count > 1 {
+			if err := r.reserve(s, len(f.Content)); err != nil {
+				return err
+			}
+
+			s.last = append([]byte(nil), f.Content...)
+			s.received[index] = true
+			s.count++
+
+			return nil
+		}
+
+		size := len(f.Content)
+		if s.last != nil && len(s.last) > size {
+			return ErrInvalidSplit
+		}
+
+		if err := r.reserve(s, size*count); err != nil {
+			return err
+		}
+
+		s.buf = buffer.New(size * count)
+		s.size = size
+
+		if s.last != nil {
+			s.buf.SetOffset(s.size * (count - 1))
+			if _, err := s.buf.Write(s.last); err != nil {
+				return err
+			}
+
+			s.length = s.size*(count-1) + len(s.last)
+			s.last = nil
+		}
+	}
+
+	if lastIndex && len(f.Content) > s.size || !lastIndex && len(f.Content) != s.size {
+		return ErrInvalidSplit
+	}
+
+	s.buf.SetOffset(s.size * index)
+	if _, err := s.buf.Write(f.Content); err != nil {
+		return err
+	}
+
+	if lastIndex {
+		s.length = s.size*index + len(f.Content)
+	}
+
+	s.received[index] = true
+	s.count++
+
+	return nil
+}
+
+// Accounts the provided number of bytes to the split packet, releasing any bytes it reserved before
+func (r *Reassembler) reserve(s *split, n int) error {
+	if r.bytes-s.reserved+n > r.maxBytes() {
+		return ErrTooManySplits
+	}
+
+	r.bytes += n - s.reserved
+	s.reserved = n
+
+	return nil
+}
+
+// Removes the split packet with the provided id and releases the bytes it reserved
+func (r *Reassembler) discard(id uint16) {
+	if s, ok := r.splits[id]; ok {
+		r.bytes -= s.reserved
+		delete(r.splits, id)
+	}
+}
+
+// Discards the split packets that received no fragment within the timeout before the provided time and
+// returns the number of split packets discarded.
+func (r *Reassembler) Expire(now time.Time) int {
+	n := 0
+	for id, s := range r.splits {
+		if now.Sub(s.updated) > r.timeout() {
+			r.discard(id)
+			n++
+		}
+	}
+
+	return n
+}
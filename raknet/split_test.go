@@ -0,0 +1,179 @@
+package raknet
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+)
+
+// Splits the provided payload into frames of the provided fragment size
+func fragments(id uint16, payload []byte, size int) []*Frame {
+	count := (len(payload) + size - 1) / size
+
+	frames := make([]*Frame, count)
+	for i := range frames {
+		end := min((i+1)*size, len(payload))
+		frames[i] = &Frame{
+			Split: true, SplitCount: uint32(count), SplitID: id, SplitIndex: uint32(i),
+			Content: payload[i*size : end],
+		}
+	}
+
+	return frames
+}
+
+func TestReassemble(t *testing.T) {
+	payload := bytes.Repeat([]byte("reassembled"), 100)
+
+	tests := []struct {
+		name  string
+		order []int
+	}{
+		{"in order", []int{0, 1, 2, 3}},
+		{"reversed", []int{3, 2, 1, 0}},
+		{"last first", []int{3, 0, 2, 1}},
+		{"duplicates", []int{1, 1, 0, 3, 0, 2}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var r Reassembler
+			frames := fragments(1, payload, 300)
+
+			for i, index := range tt.order {
+				b, err := r.Add(frames[index])
+				if err != nil {
+					t.Fatalf("Add(%d) error = %v", index, err)
+				}
+
+				if i < len(tt.order)-1 {
+					if b != nil {
+						t.Fatalf("Add(%d) returned a packet before every fragment was received", index)
+					}
+					continue
+				}
+
+				if b == nil || !bytes.Equal(b.Slice()[:b.Length()], payload) || b.Offset() != 0 {
+					t.Fatal("Add() of the final fragment did not return the reassembled packet")
+				}
+			}
+
+			if r.Pending() != 0 || r.bytes != 0 {
+				t.Fatalf("%d split packets and %d bytes still pending", r.Pending(), r.bytes)
+			}
+		})
+	}
+}
+
+func TestReassembleLastFirstTooLarge(t *testing.T) {
+	var r Reassembler
+	frames := fragments(1, make([]byte, 900), 300)
+
+	// The last fragment arrives first and is larger than the fragments that follow.
+	last := *frames[2]
+	last.Content = make([]byte, 400)
+
+	if _, err := r.Add(&last); err != nil {
+		t.Fatalf("Add() of the last fragment error = %v", err)
+	}
+
+	if _, err := r.Add(frames[0]); !errors.Is(err, ErrInvalidSplit) {
+		t.Fatalf("Add() error = %v, want ErrInvalidSplit", err)
+	}
+
+	if r.Pending() != 0 || r.bytes != 0 {
+		t.Fatal("the inconsistent split packet was not discarded")
+	}
+}
+
+func TestReassembleInconsistentCount(t *testing.T) {
+	var r Reassembler
+	frames := fragments(1, make([]byte, 900), 300)
+
+	r.Add(frames[0])
+
+	other := *frames[1]
+	other.SplitCount = 4
+	if _, err := r.Add(&other); !errors.Is(err, ErrInvalidSplit) || r.Pending() != 0 {
+		t.Fatalf("Add() error = %v with %d pending, want ErrInvalidSplit and the split discarded", err, r.Pending())
+	}
+}
+
+func TestReassembleFragmentLimit(t *testing.T) {
+	r := Reassembler{MaxFragments: 4}
+
+	if _, err := r.Add(fragments(1, make([]byte, 5), 1)[0]); !errors.Is(err, ErrTooManySplits) {
+		t.Fatalf("Add() of a split with 5 fragments error = %v, want ErrTooManySplits", err)
+	}
+
+	if r.Pending() != 0 {
+		t.Fatal("a split packet exceeding the fragment limit was kept")
+	}
+}
+
+func TestReassembleSplitLimit(t *testing.T) {
+	r := Reassembler{MaxSplits: 2}
+
+	for id := uint16(0); id < 2; id++ {
+		if _, err := r.Add(fragments(id, make([]byte, 4), 2)[0]); err != nil {
+			t.Fatalf("Add() of split %d error = %v", id, err)
+		}
+	}
+
+	if _, err := r.Add(fragments(2, make([]byte, 4), 2)[0]); !errors.Is(err, ErrTooManySplits) {
+		t.Fatalf("Add() of a third split error = %v, want ErrTooManySplits", err)
+	}
+
+	// Fragments of split packets already being reassembled are still accepted.
+	if b, err := r.Add(fragments(1, make([]byte, 4), 2)[1]); err != nil || b == nil {
+		t.Fatalf("Add() of the final fragment of split 1 = %v, %v", b, err)
+	}
+}
+
+func TestReassembleByteLimit(t *testing.T) {
+	r := Reassembler{MaxBytes: 1000}
+
+	// The first fragment reserves its size times the fragment count.
+	if _, err := r.Add(fragments(1, make([]byte, 1200), 400)[0]); !errors.Is(err, ErrTooManySplits) {
+		t.Fatalf("Add() error = %v, want ErrTooManySplits", err)
+	}
+
+	if r.bytes != 0 || r.Pending() != 0 {
+		t.Fatalf("%d bytes and %d split packets left after a rejected fragment", r.bytes, r.Pending())
+	}
+
+	if _, err := r.Add(fragments(1, make([]byte, 900), 300)[0]); err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+
+	if _, err := r.Add(fragments(2, make([]byte, 200), 100)[0]); !errors.Is(err, ErrTooManySplits) {
+		t.Fatalf("Add() beyond the bytes reserved by all split packets error = %v, want ErrTooManySplits", err)
+	}
+
+	// A last fragment arriving first reserves its own size until the size of the others is known.
+	r = Reassembler{MaxBytes: 1000}
+	last := fragments(1, make([]byte, 1100), 1000)[1]
+	if _, err := r.Add(last); err != nil || r.bytes != 100 {
+		t.Fatalf("Add() of the last fragment = %v with %d bytes reserved, want 100", err, r.bytes)
+	}
+
+	if _, err := r.Add(fragments(1, make([]byte, 1100), 1000)[0]); !errors.Is(err, ErrTooManySplits) || r.bytes != 0 {
+		t.Fatalf("Add() error = %v with %d bytes reserved, want ErrTooManySplits and nothing reserved", err, r.bytes)
+	}
+}
+
+func TestReassembleTimeout(t *testing.T) {
+	r := Reassembler{Timeout: time.Minute}
+	frames := fragments(1, make([]byte, 900), 300)
+
+	r.Add(frames[0])
+
+	if n := r.Expire(time.Now()); n != 0 || r.Pending() != 1 {
+		t.Fatalf("Expire() before the timeout discarded %d split packets", n)
+	}
+
+	if n := r.Expire(time.Now().Add(2 * time.Minute)); n != 1 || r.Pending() != 0 || r.bytes != 0 {
+		t.Fatalf("Expire() after the timeout discarded %d split packets with %d bytes left", n, r.bytes)
+	}
+}